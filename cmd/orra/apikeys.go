@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type apiKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Key        string     `json:"key,omitempty"`
+}
+
+func newAPIKeysCmd() *ffcli.Command {
+	return &ffcli.Command{
+		Name:       "api-keys",
+		ShortUsage: "orra api-keys COMMAND",
+		ShortHelp:  "Add and manage API keys for a project",
+		FlagSet:    flag.NewFlagSet("orra api-keys", flag.ExitOnError),
+		Subcommands: []*ffcli.Command{
+			newAPIKeysAddCmd(),
+			newAPIKeysLsCmd(),
+			newAPIKeysRevokeCmd(),
+		},
+		Exec: func(context.Context, []string) error {
+			return flag.ErrHelp
+		},
+	}
+}
+
+func newAPIKeysAddCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra api-keys add", flag.ExitOnError)
+	opts.register(fs, true)
+	name := fs.String("name", "", "name of the API key")
+	scopes := fs.String("scopes", "", "comma separated scopes granted to the key (orchestrate, register-service, read-only, manage), defaults to all")
+
+	return &ffcli.Command{
+		Name:       "add",
+		ShortUsage: "orra api-keys add --name NAME [--scopes SCOPES] [-p PROJECT]",
+		ShortHelp:  "Generate a new API key for a project",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			if strings.TrimSpace(*name) == "" {
+				return errors.New("an API key --name is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			request := map[string]any{"name": *name, "scopes": splitList(*scopes)}
+			var created apiKey
+			if err := NewClient(cfg.URL, project.APIKey).Post(ctx, "/projects/"+project.ID+"/api-keys", request, &created); err != nil {
+				return err
+			}
+
+			if opts.output == outputJSON {
+				return printJSON(os.Stdout, created)
+			}
+
+			fmt.Printf("API key %s created with scopes: %s\n", created.Name, strings.Join(created.Scopes, ", "))
+			fmt.Printf("\n    %s\n\n", created.Key)
+			fmt.Println("Store it somewhere safe, it cannot be shown again.")
+			return nil
+		},
+	}
+}
+
+func newAPIKeysLsCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra api-keys ls", flag.ExitOnError)
+	opts.register(fs, true)
+	all := fs.Bool("a", false, "include revoked API keys")
+
+	return &ffcli.Command{
+		Name:       "ls",
+		ShortUsage: "orra api-keys ls [-p PROJECT] [-a]",
+		ShortHelp:  "List a project's API keys",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var keys []apiKey
+			if err := NewClient(cfg.URL, project.APIKey).Get(ctx, "/projects/"+project.ID+"/api-keys", &keys); err != nil {
+				return err
+			}
+
+			listed := make([]apiKey, 0, len(keys))
+			var rows [][]string
+			for _, key := range keys {
+				if key.RevokedAt != nil && !*all {
+					continue
+				}
+				listed = append(listed, key)
+
+				lastUsed, status := "-", "active"
+				if key.LastUsedAt != nil {
+					lastUsed = ago(*key.LastUsedAt)
+				}
+				if key.RevokedAt != nil {
+					status = "revoked"
+				}
+				rows = append(rows, []string{
+					key.ID,
+					key.Name,
+					key.Prefix + "...",
+					strings.Join(key.Scopes, ","),
+					ago(key.CreatedAt),
+					lastUsed,
+					status,
+				})
+			}
+
+			return render(opts.output, listed, []string{"ID", "NAME", "KEY", "SCOPES", "CREATED", "LAST USED", "STATUS"}, rows)
+		},
+	}
+}
+
+func newAPIKeysRevokeCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra api-keys revoke", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "revoke",
+		ShortUsage: "orra api-keys revoke [-p PROJECT] KEY_ID",
+		ShortHelp:  "Revoke one of a project's API keys",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a KEY_ID is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var revoked apiKey
+			path := fmt.Sprintf("/projects/%s/api-keys/%s", project.ID, url.PathEscape(args[0]))
+			if err := NewClient(cfg.URL, project.APIKey).Delete(ctx, path, &revoked); err != nil {
+				return err
+			}
+
+			fmt.Printf("Revoked API key %s\n", revoked.Name)
+			return nil
+		},
+	}
+}
+
+func splitList(val string) []string {
+	out := []string{}
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+func newLoginCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra login", flag.ExitOnError)
+	opts.register(fs, false)
+	url := fs.String("url", "http://localhost:8005", "control plane URL")
+	adminKey := fs.String("admin-key", "", "control plane admin key, required to add projects")
+
+	return &ffcli.Command{
+		Name:       "login",
+		ShortUsage: "orra login [--url URL] [--admin-key KEY]",
+		ShortHelp:  "Log in to a control plane",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, err := LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+
+			var serverVersion struct {
+				Version string `json:"version"`
+			}
+			if err := NewClient(*url, "").Get(ctx, "/version", &serverVersion); err != nil {
+				return err
+			}
+
+			if *adminKey != "" {
+				var projects []ProjectConfig
+				if err := NewClient(*url, *adminKey).Get(ctx, "/projects", &projects); err != nil {
+					return fmt.Errorf("invalid admin key: %w", err)
+				}
+				cfg.AdminKey = *adminKey
+			}
+
+			cfg.URL = *url
+			if err := cfg.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Logged in to %s (control plane %s)\n", *url, serverVersion.Version)
+			return nil
+		},
+	}
+}
+
+func newLogoutCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra logout", flag.ExitOnError)
+	opts.register(fs, false)
+
+	return &ffcli.Command{
+		Name:       "logout",
+		ShortUsage: "orra logout",
+		ShortHelp:  "Log out from a control plane and forget its credentials",
+		FlagSet:    fs,
+		Exec: func(context.Context, []string) error {
+			cfg, err := LoadConfig(opts.configPath)
+			if err != nil {
+				return err
+			}
+			if cfg.URL == "" {
+				return errors.New("not logged in")
+			}
+
+			url := cfg.URL
+			cfg.URL = ""
+			cfg.AdminKey = ""
+			cfg.CurrentProject = ""
+			cfg.Projects = make(map[string]*ProjectConfig)
+			if err := cfg.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Logged out from %s\n", url)
+			return nil
+		},
+	}
+}
+
+func newVersionCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra version", flag.ExitOnError)
+	opts.register(fs, false)
+
+	return &ffcli.Command{
+		Name:       "version",
+		ShortUsage: "orra version",
+		ShortHelp:  "Print the client and server version information",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			versions := map[string]string{"client": version, "server": "unknown"}
+
+			if cfg, err := LoadConfig(opts.configPath); err == nil && cfg.URL != "" {
+				var serverVersion struct {
+					Version string `json:"version"`
+				}
+				if err := NewClient(cfg.URL, "").Get(ctx, "/version", &serverVersion); err == nil {
+					versions["server"] = serverVersion.Version
+				}
+			}
+
+			return render(opts.output, versions,
+				[]string{"CLIENT", "SERVER"},
+				[][]string{{versions["client"], versions["server"]}},
+			)
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientReportsAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-orra-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api-error":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"kind":"input_validation_error","message":"invalid scope: admin"}}`))
+		case "/text-error":
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte(`{"name":"demo"}`))
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		apiKey string
+		path   string
+		want   string
+	}{
+		{"sk-orra-1", "/api-error", "400 Bad Request: invalid scope: admin"},
+		{"sk-orra-1", "/text-error", "502 Bad Gateway: upstream unavailable"},
+		{"", "/projects", "401 Unauthorized"},
+	}
+	for _, tc := range testCases {
+		err := NewClient(server.URL, tc.apiKey).Get(context.Background(), tc.path, nil)
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%s: got error %v, want %q", tc.path, err, tc.want)
+		}
+	}
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := NewClient(server.URL+"/", "sk-orra-1").Get(context.Background(), "/projects", &out); err != nil || out.Name != "demo" {
+		t.Errorf("got %+v, err %v", out, err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config is the CLI's local state, it includes credentials so it's only readable by the current user.
+type Config struct {
+	URL            string                    `json:"url,omitempty"`
+	AdminKey       string                    `json:"adminKey,omitempty"`
+	CurrentProject string                    `json:"currentProject,omitempty"`
+	Projects       map[string]*ProjectConfig `json:"projects,omitempty"`
+
+	path string
+}
+
+type ProjectConfig struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	APIKey string `json:"apiKey"`
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("ORRA_CONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".orra.json"
+	}
+	return filepath.Join(home, ".orra", "config.json")
+}
+
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{Projects: make(map[string]*ProjectConfig), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if cfg.Projects == nil {
+		cfg.Projects = make(map[string]*ProjectConfig)
+	}
+	return cfg, nil
+}
+
+func (c *Config) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(c.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config %s: %w", c.path, err)
+	}
+	// WriteFile only sets the permissions of new files, a config created by hand may be readable by others
+	if err := os.Chmod(c.path, 0o600); err != nil {
+		return fmt.Errorf("failed to restrict permissions of config %s: %w", c.path, err)
+	}
+	return nil
+}
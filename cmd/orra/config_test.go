@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigSavesAndLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orra", "config.json")
+
+	cfg, err := LoadConfig(path)
+	if err != nil || cfg.URL != "" || cfg.Projects == nil {
+		t.Fatalf("expected a missing config to load empty, got %+v, err %v", cfg, err)
+	}
+
+	cfg.URL = "http://localhost:8005"
+	cfg.CurrentProject = "demo"
+	cfg.Projects["demo"] = &ProjectConfig{ID: "p1", Name: "demo", APIKey: "sk-orra-1"}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil || loaded.URL != cfg.URL || loaded.Projects["demo"].APIKey != "sk-orra-1" {
+		t.Errorf("got config %+v, err %v", loaded, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "failed to parse config") {
+		t.Errorf("expected a corrupt config to be reported, got %v", err)
+	}
+}
+
+func TestConfigSaveRestrictsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	cfg.AdminKey = "secret"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the config to only be readable by its owner, got %v, err %v", info.Mode().Perm(), err)
+	}
+}
+
+func TestOptionsResolveProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg, _ := LoadConfig(path)
+	cfg.URL = "http://localhost:8005"
+	cfg.Projects["demo"] = &ProjectConfig{ID: "p1", Name: "demo"}
+	cfg.Projects["other"] = &ProjectConfig{ID: "p2", Name: "other"}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	if _, _, err := (&options{configPath: filepath.Join(t.TempDir(), "missing.json")}).load(); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("expected a missing config to require logging in, got %v", err)
+	}
+	if _, _, err := (&options{configPath: path}).loadProject(); err == nil || !strings.Contains(err.Error(), "no project selected") {
+		t.Errorf("expected a project to be required, got %v", err)
+	}
+
+	cfg.CurrentProject = "demo"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	testCases := []struct {
+		project string
+		want    string
+		wantErr string
+	}{
+		{project: "", want: "p1"},
+		{project: "other", want: "p2"},
+		{project: "missing", wantErr: "unknown project missing"},
+	}
+	for _, tc := range testCases {
+		_, project, err := (&options{configPath: path, project: tc.project}).loadProject()
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("project %q: expected error %q, got %v", tc.project, tc.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || project.ID != tc.want {
+			t.Errorf("project %q: got %+v, err %v, want %s", tc.project, project, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/ezodude/orra/cmd/orra
+
+go 1.22.5
+
+require github.com/peterbourgon/ff/v3 v3.4.0
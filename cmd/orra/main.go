@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+// version is set at build time, e.g. go build -ldflags "-X main.version=v0.1.0"
+var version = "dev"
+
+func main() {
+	root := &ffcli.Command{
+		Name:       "orra",
+		ShortUsage: "orra [OPTIONS] COMMAND",
+		ShortHelp:  "orra manages Orra and orchestration workflows.",
+		FlagSet:    flag.NewFlagSet("orra", flag.ExitOnError),
+		Subcommands: []*ffcli.Command{
+			newProjectsCmd(),
+			newWebhooksCmd(),
+			newWorkflowsCmd(),
+			newAPIKeysCmd(),
+			newPsCmd(),
+			newInspectCmd(),
+			newLogsCmd(),
+			newDeliveriesCmd(),
+			newRedeliverCmd(),
+			newLoginCmd(),
+			newLogoutCmd(),
+			newVersionCmd(),
+		},
+		Exec: func(context.Context, []string) error {
+			return flag.ErrHelp
+		},
+	}
+
+	if err := root.ParseAndRun(context.Background(), os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// options are the flags shared by the commands talking to the control plane.
+type options struct {
+	configPath string
+	project    string
+	output     string
+}
+
+func (o *options) register(fs *flag.FlagSet, withProject bool) {
+	fs.StringVar(&o.configPath, "config", defaultConfigPath(), "path to the orra config file")
+	fs.StringVar(&o.output, "o", outputTable, "output format (table, json)")
+	if withProject {
+		fs.StringVar(&o.project, "p", "", "project name, defaults to the current project")
+	}
+}
+
+// load reads the config and resolves the project the command applies to.
+func (o *options) load() (*Config, *ProjectConfig, error) {
+	cfg, err := LoadConfig(o.configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.URL == "" {
+		return nil, nil, errors.New("not logged in, run: orra login")
+	}
+
+	name := o.project
+	if name == "" {
+		name = cfg.CurrentProject
+	}
+	if name == "" {
+		return cfg, nil, nil
+	}
+
+	project, ok := cfg.Projects[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown project %s, run: orra projects ls", name)
+	}
+	return cfg, project, nil
+}
+
+// loadProject is like load but requires a project.
+func (o *options) loadProject() (*Config, *ProjectConfig, error) {
+	cfg, project, err := o.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	if project == nil {
+		return nil, nil, errors.New("no project selected, use -p or run: orra projects use NAME")
+	}
+	return cfg, project, nil
+}
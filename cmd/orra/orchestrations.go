@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type orchestrationSummary struct {
+	ID     string `json:"id"`
+	Action struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	} `json:"action"`
+	Status    string          `json:"status"`
+	Error     json.RawMessage `json:"error,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+type orchestrationPage struct {
+	Orchestrations []orchestrationSummary `json:"orchestrations"`
+	NextCursor     string                 `json:"nextCursor,omitempty"`
+}
+
+type orchestrationInspection struct {
+	orchestrationSummary
+	Data             json.RawMessage   `json:"data"`
+	Plan             json.RawMessage   `json:"plan"`
+	Results          []json.RawMessage `json:"results"`
+	PlanningAttempts []struct {
+		Attempt int    `json:"attempt"`
+		Error   string `json:"error,omitempty"`
+	} `json:"planning_attempts"`
+	Tasks []struct {
+		ID             string `json:"id"`
+		Service        string `json:"service"`
+		ServiceVersion int64  `json:"service_version"`
+		Level          int    `json:"level"`
+		Status         string `json:"status"`
+	} `json:"tasks"`
+}
+
+type logEntry struct {
+	Offset     uint64          `json:"offset"`
+	Type       string          `json:"type"`
+	ID         string          `json:"id"`
+	Value      json.RawMessage `json:"value"`
+	Timestamp  time.Time       `json:"timestamp"`
+	ProducerID string          `json:"producer_id"`
+}
+
+func newPsCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra ps", flag.ExitOnError)
+	opts.register(fs, true)
+	status := fs.String("status", "", "only list orchestrations with these comma separated statuses")
+	since := fs.Duration("since", 0, "only list orchestrations created within this duration, e.g. 1h")
+	limit := fs.Int("limit", 20, "maximum number of orchestrations to list")
+	all := fs.Bool("a", false, "list all orchestrations, fetching every page")
+
+	return &ffcli.Command{
+		Name:       "ps",
+		ShortUsage: "orra ps [-p PROJECT] [--status STATUS] [--since DURATION] [-a]",
+		ShortHelp:  "List orchestrations for a project",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			query := url.Values{}
+			query.Set("limit", strconv.Itoa(*limit))
+			if *status != "" {
+				query.Set("status", *status)
+			}
+			if *since > 0 {
+				query.Set("since", time.Now().Add(-*since).UTC().Format(time.RFC3339))
+			}
+
+			client := NewClient(cfg.URL, project.APIKey)
+			var orchestrations []orchestrationSummary
+			for {
+				var page orchestrationPage
+				if err := client.Get(ctx, "/orchestrations?"+query.Encode(), &page); err != nil {
+					return err
+				}
+				orchestrations = append(orchestrations, page.Orchestrations...)
+				if !*all || page.NextCursor == "" {
+					break
+				}
+				query.Set("cursor", page.NextCursor)
+			}
+
+			rows := make([][]string, 0, len(orchestrations))
+			for _, o := range orchestrations {
+				rows = append(rows, []string{o.ID, o.Action.Type, truncate(o.Action.Content, 40), o.Status, ago(o.Timestamp)})
+			}
+
+			if orchestrations == nil {
+				orchestrations = []orchestrationSummary{}
+			}
+			return render(opts.output, orchestrations, []string{"ID", "TYPE", "ACTION", "STATUS", "CREATED"}, rows)
+		},
+	}
+}
+
+func newInspectCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra inspect", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "inspect",
+		ShortUsage: "orra inspect [-p PROJECT] ORCHESTRATION_ID",
+		ShortHelp:  "Return information of an orchestration",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("an ORCHESTRATION_ID is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var inspection orchestrationInspection
+			client := NewClient(cfg.URL, project.APIKey)
+			if err := client.Get(ctx, "/orchestrations/"+url.PathEscape(args[0]), &inspection); err != nil {
+				return err
+			}
+
+			if opts.output == outputJSON {
+				return printJSON(os.Stdout, inspection)
+			}
+
+			fmt.Printf("ID:       %s\n", inspection.ID)
+			fmt.Printf("Action:   %s\n", inspection.Action.Content)
+			fmt.Printf("Status:   %s\n", inspection.Status)
+			fmt.Printf("Created:  %s\n", inspection.Timestamp.Format(time.RFC3339))
+			if len(inspection.Error) > 0 {
+				fmt.Printf("Error:    %s\n", inspection.Error)
+			}
+			for _, result := range inspection.Results {
+				fmt.Printf("Result:   %s\n", result)
+			}
+			for _, attempt := range inspection.PlanningAttempts {
+				if attempt.Error != "" {
+					fmt.Printf("Plan %d:   rejected: %s\n", attempt.Attempt, attempt.Error)
+				}
+			}
+			var plan struct {
+				DAG struct {
+					CriticalPath []string `json:"critical_path"`
+				} `json:"dag"`
+			}
+			if json.Unmarshal(inspection.Plan, &plan) == nil && len(plan.DAG.CriticalPath) > 0 {
+				fmt.Printf("Critical: %s\n", strings.Join(plan.DAG.CriticalPath, " -> "))
+			}
+			fmt.Println()
+
+			rows := make([][]string, 0, len(inspection.Tasks))
+			for _, task := range inspection.Tasks {
+				version := "-"
+				if task.ServiceVersion > 0 {
+					version = strconv.FormatInt(task.ServiceVersion, 10)
+				}
+				rows = append(rows, []string{task.ID, task.Service, version, strconv.Itoa(task.Level), task.Status})
+			}
+			return printTable(os.Stdout, []string{"TASK", "SERVICE", "VERSION", "LEVEL", "STATUS"}, rows)
+		},
+	}
+}
+
+func newLogsCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra logs", flag.ExitOnError)
+	opts.register(fs, true)
+	follow := fs.Bool("f", false, "follow the log until the orchestration completes")
+	offset := fs.Uint64("offset", 0, "log offset to start from")
+
+	return &ffcli.Command{
+		Name:       "logs",
+		ShortUsage: "orra logs [-p PROJECT] [-f] [--offset N] ORCHESTRATION_ID",
+		ShortHelp:  "Fetch the logs for an orchestration",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("an ORCHESTRATION_ID is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			client := NewClient(cfg.URL, project.APIKey)
+			path := fmt.Sprintf("/orchestrations/%s/logs", url.PathEscape(args[0]))
+			query := "?offset=" + strconv.FormatUint(*offset, 10)
+
+			if *follow {
+				return followLogs(ctx, client, path+"/stream"+query, opts.output)
+			}
+
+			var response struct {
+				Entries []logEntry `json:"entries"`
+			}
+			if err := client.Get(ctx, path+query, &response); err != nil {
+				return err
+			}
+			for _, entry := range response.Entries {
+				if err := printLogEntry(entry, opts.output); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// followLogs prints the entries sent by the control plane's log stream until it ends.
+func followLogs(ctx context.Context, client *Client, path string, format string) error {
+	body, err := client.Stream(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var event, data string
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "":
+			if event == "end" {
+				fmt.Fprintf(os.Stderr, "orchestration finished: %s\n", data)
+				return nil
+			}
+			if data != "" {
+				var entry logEntry
+				if err := json.Unmarshal([]byte(data), &entry); err != nil {
+					return fmt.Errorf("failed to decode log entry: %w", err)
+				}
+				if err := printLogEntry(entry, format); err != nil {
+					return err
+				}
+			}
+			event, data = "", ""
+		}
+	}
+	return scanner.Err()
+}
+
+func printLogEntry(entry logEntry, format string) error {
+	if format == outputJSON {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("%-5d %s  %-12s %-10s %s\n",
+		entry.Offset,
+		entry.Timestamp.Format(time.RFC3339),
+		entry.Type,
+		entry.ID,
+		entry.Value,
+	)
+	return nil
+}
+
+type deliveryAttempt struct {
+	ID          string    `json:"id"`
+	DeliveryID  string    `json:"deliveryId"`
+	WebhookID   string    `json:"webhookId"`
+	EventType   string    `json:"eventType"`
+	URL         string    `json:"url"`
+	Attempt     int       `json:"attempt"`
+	PayloadHash string    `json:"payloadHash"`
+	StatusCode  int       `json:"statusCode,omitempty"`
+	LatencyMs   int64     `json:"latencyMs"`
+	Response    string    `json:"response,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
+
+func newDeliveriesCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra deliveries", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "deliveries",
+		ShortUsage: "orra deliveries [-p PROJECT] ORCHESTRATION_ID",
+		ShortHelp:  "List the webhook delivery attempts of an orchestration",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("an ORCHESTRATION_ID is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var attempts []deliveryAttempt
+			path := "/orchestrations/" + url.PathEscape(args[0]) + "/deliveries"
+			if err := NewClient(cfg.URL, project.APIKey).Get(ctx, path, &attempts); err != nil {
+				return err
+			}
+
+			rows := make([][]string, 0, len(attempts))
+			for _, attempt := range attempts {
+				status := "-"
+				if attempt.StatusCode != 0 {
+					status = fmt.Sprint(attempt.StatusCode)
+				}
+				rows = append(rows, []string{
+					attempt.DeliveryID,
+					attempt.EventType,
+					attempt.URL,
+					fmt.Sprint(attempt.Attempt),
+					status,
+					fmt.Sprintf("%dms", attempt.LatencyMs),
+					truncate(attempt.Error, 40),
+					ago(attempt.AttemptedAt),
+				})
+			}
+
+			return render(opts.output, attempts, []string{"DELIVERY ID", "EVENT", "URL", "ATTEMPT", "STATUS", "LATENCY", "ERROR", "ATTEMPTED"}, rows)
+		},
+	}
+}
+
+func newRedeliverCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra redeliver", flag.ExitOnError)
+	opts.register(fs, true)
+	webhookID := fs.String("webhook", "", "only redeliver to the webhook with this id")
+
+	return &ffcli.Command{
+		Name:       "redeliver",
+		ShortUsage: "orra redeliver [-p PROJECT] [--webhook WEBHOOK_ID] ORCHESTRATION_ID",
+		ShortHelp:  "Send a finished orchestration's result to its webhooks again",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("an ORCHESTRATION_ID is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var deliveries []struct {
+				ID string `json:"id"`
+			}
+			path := "/orchestrations/" + url.PathEscape(args[0]) + "/deliveries/redeliver"
+			request := map[string]string{"webhookId": *webhookID}
+			if err := NewClient(cfg.URL, project.APIKey).Post(ctx, path, request, &deliveries); err != nil {
+				return err
+			}
+
+			fmt.Printf("Redelivering orchestration %s to %d webhooks\n", args[0], len(deliveries))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPsRendersTablesAndJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orchestrations" || r.URL.Query().Get("status") != "failed" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"orchestrations":[{"id":"o1","action":{"type":"echo","content":"Echo this"},"status":"failed","timestamp":"` +
+			time.Now().Add(-5*time.Minute).Format(time.RFC3339) + `"}]}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	cfg, _ := LoadConfig(path)
+	cfg.URL = server.URL
+	cfg.CurrentProject = "demo"
+	cfg.Projects["demo"] = &ProjectConfig{ID: "p1", Name: "demo", APIKey: "sk-orra-1"}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	table := captureStdout(t, func() error {
+		return newPsCmd().ParseAndRun(context.Background(), []string{"-config", path, "-status", "failed"})
+	})
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 2 || strings.Join(strings.Fields(lines[0]), " ") != "ID TYPE ACTION STATUS CREATED" {
+		t.Fatalf("unexpected table:\n%s", table)
+	}
+	if got := strings.Join(strings.Fields(lines[1]), " "); got != "o1 echo Echo this failed 5m ago" {
+		t.Errorf("unexpected row %q", got)
+	}
+
+	output := captureStdout(t, func() error {
+		return newPsCmd().ParseAndRun(context.Background(), []string{"-config", path, "-status", "failed", "-o", "json"})
+	})
+	var orchestrations []orchestrationSummary
+	if err := json.Unmarshal([]byte(output), &orchestrations); err != nil || len(orchestrations) != 1 || orchestrations[0].ID != "o1" {
+		t.Errorf("unexpected JSON output %s, err %v", output, err)
+	}
+
+	if err := render("yaml", nil, nil, nil); err == nil {
+		t.Errorf("expected an unknown output format to be rejected")
+	}
+}
+
+// captureStdout returns what the function prints to stdout.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string, 1)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+
+	runErr := fn()
+	_ = w.Close()
+	if runErr != nil {
+		t.Fatalf("command failed: %v", runErr)
+	}
+	return <-output
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+func newProjectsCmd() *ffcli.Command {
+	return &ffcli.Command{
+		Name:       "projects",
+		ShortUsage: "orra projects COMMAND",
+		ShortHelp:  "Add and manage projects",
+		FlagSet:    flag.NewFlagSet("orra projects", flag.ExitOnError),
+		Subcommands: []*ffcli.Command{
+			newProjectsAddCmd(),
+			newProjectsLsCmd(),
+			newProjectsUseCmd(),
+			newProjectsInspectCmd(),
+			newProjectsRmCmd(),
+		},
+		Exec: func(context.Context, []string) error {
+			return flag.ErrHelp
+		},
+	}
+}
+
+func newProjectsAddCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra projects add", flag.ExitOnError)
+	opts.register(fs, false)
+	webhook := fs.String("webhook", "", "webhook URL receiving orchestration results")
+
+	return &ffcli.Command{
+		Name:       "add",
+		ShortUsage: "orra projects add [--webhook URL] NAME",
+		ShortHelp:  "Add a new project",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a project NAME is required")
+			}
+			name := args[0]
+
+			cfg, _, err := opts.load()
+			if err != nil {
+				return err
+			}
+			if _, exists := cfg.Projects[name]; exists {
+				return fmt.Errorf("project %s already exists", name)
+			}
+			if cfg.AdminKey == "" {
+				return errors.New("adding projects requires the admin key, run: orra login --admin-key KEY")
+			}
+
+			var project ProjectConfig
+			request := map[string]string{"name": name, "webhook": *webhook}
+			if err := NewClient(cfg.URL, cfg.AdminKey).Post(ctx, "/register/project", request, &project); err != nil {
+				return err
+			}
+			project.Name = name
+
+			cfg.Projects[name] = &project
+			if cfg.CurrentProject == "" {
+				cfg.CurrentProject = name
+			}
+			if err := cfg.Save(); err != nil {
+				return err
+			}
+
+			return render(opts.output, project,
+				[]string{"NAME", "ID", "API KEY"},
+				[][]string{{project.Name, project.ID, project.APIKey}},
+			)
+		},
+	}
+}
+
+func newProjectsLsCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra projects ls", flag.ExitOnError)
+	opts.register(fs, false)
+
+	return &ffcli.Command{
+		Name:       "ls",
+		ShortUsage: "orra projects ls",
+		ShortHelp:  "List projects",
+		FlagSet:    fs,
+		Exec: func(context.Context, []string) error {
+			cfg, _, err := opts.load()
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(cfg.Projects))
+			for name := range cfg.Projects {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			projects := make([]*ProjectConfig, 0, len(names))
+			var rows [][]string
+			for _, name := range names {
+				project := cfg.Projects[name]
+				projects = append(projects, project)
+
+				current := ""
+				if name == cfg.CurrentProject {
+					current = "*"
+				}
+				rows = append(rows, []string{current, project.Name, project.ID})
+			}
+
+			return render(opts.output, projects, []string{"CURRENT", "NAME", "ID"}, rows)
+		},
+	}
+}
+
+func newProjectsUseCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra projects use", flag.ExitOnError)
+	opts.register(fs, false)
+
+	return &ffcli.Command{
+		Name:       "use",
+		ShortUsage: "orra projects use NAME",
+		ShortHelp:  "Set the current project used by other commands",
+		FlagSet:    fs,
+		Exec: func(_ context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a project NAME is required")
+			}
+
+			cfg, _, err := opts.load()
+			if err != nil {
+				return err
+			}
+			if _, exists := cfg.Projects[args[0]]; !exists {
+				return fmt.Errorf("unknown project %s", args[0])
+			}
+
+			cfg.CurrentProject = args[0]
+			if err := cfg.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Now using project %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+type projectDetails struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Webhooks []webhook `json:"webhooks"`
+	Services []struct {
+		ID      string `json:"id"`
+		Name    string `json:"name"`
+		Type    string `json:"type"`
+		Version int64  `json:"version"`
+	} `json:"services"`
+	Orchestrations int `json:"orchestrations"`
+}
+
+func newProjectsInspectCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra projects inspect", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "inspect",
+		ShortUsage: "orra projects inspect [-p PROJECT]",
+		ShortHelp:  "Return information of a project and its services",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var details projectDetails
+			if err := NewClient(cfg.URL, project.APIKey).Get(ctx, "/projects/"+project.ID, &details); err != nil {
+				return err
+			}
+
+			if opts.output == outputJSON {
+				return printJSON(os.Stdout, details)
+			}
+
+			fmt.Printf("ID:              %s\n", details.ID)
+			fmt.Printf("Name:            %s\n", project.Name)
+			fmt.Printf("Webhooks:        %d\n", len(details.Webhooks))
+			fmt.Printf("Orchestrations:  %d\n", details.Orchestrations)
+			fmt.Println()
+
+			rows := make([][]string, 0, len(details.Services))
+			for _, service := range details.Services {
+				rows = append(rows, []string{service.ID, service.Name, service.Type, fmt.Sprint(service.Version)})
+			}
+			return printTable(os.Stdout, []string{"SERVICE ID", "NAME", "TYPE", "VERSION"}, rows)
+		},
+	}
+}
+
+func newProjectsRmCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra projects rm", flag.ExitOnError)
+	opts.register(fs, false)
+	force := fs.Bool("f", false, "do not ask for confirmation")
+
+	return &ffcli.Command{
+		Name:       "rm",
+		ShortUsage: "orra projects rm [-f] NAME",
+		ShortHelp:  "Remove a project along with its services and orchestrations",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a project NAME is required")
+			}
+			name := args[0]
+
+			cfg, _, err := opts.load()
+			if err != nil {
+				return err
+			}
+			project, exists := cfg.Projects[name]
+			if !exists {
+				return fmt.Errorf("unknown project %s", name)
+			}
+
+			if !*force && !confirm(fmt.Sprintf("Remove project %s with all its services and orchestrations?", name)) {
+				return nil
+			}
+
+			if err := NewClient(cfg.URL, project.APIKey).Delete(ctx, "/projects/"+project.ID, nil); err != nil {
+				return err
+			}
+
+			delete(cfg.Projects, name)
+			if cfg.CurrentProject == name {
+				cfg.CurrentProject = ""
+			}
+			if err := cfg.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed project %s\n", name)
+			return nil
+		},
+	}
+}
+
+// confirm asks the user a yes/no question on the terminal.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type webhookDelivery struct {
+	ID              string     `json:"id"`
+	WebhookID       string     `json:"webhookId"`
+	URL             string     `json:"url"`
+	EventType       string     `json:"eventType"`
+	OrchestrationID string     `json:"orchestrationId"`
+	Attempts        int        `json:"attempts"`
+	LastError       string     `json:"lastError,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	DeadAt          *time.Time `json:"deadAt,omitempty"`
+}
+
+type webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newWebhooksCmd() *ffcli.Command {
+	return &ffcli.Command{
+		Name:       "webhooks",
+		ShortUsage: "orra webhooks COMMAND",
+		ShortHelp:  "Add and manage webhooks for a project",
+		FlagSet:    flag.NewFlagSet("orra webhooks", flag.ExitOnError),
+		Subcommands: []*ffcli.Command{
+			newWebhooksAddCmd(),
+			newWebhooksLsCmd(),
+			newWebhooksRmCmd(),
+			newWebhooksSecretCmd(),
+			newWebhooksDeadLettersCmd(),
+			newWebhooksRedriveCmd(),
+		},
+		Exec: func(context.Context, []string) error {
+			return flag.ErrHelp
+		},
+	}
+}
+
+func newWebhooksAddCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra webhooks add", flag.ExitOnError)
+	opts.register(fs, true)
+	webhookURL := fs.String("url", "", "URL receiving the project's events")
+	events := fs.String("events", "", "comma separated events to subscribe to (orchestration.completed, orchestration.failed, orchestration.not_actionable, task.completed, task.failed), defaults to every orchestration outcome")
+
+	return &ffcli.Command{
+		Name:       "add",
+		ShortUsage: "orra webhooks add --url URL [--events EVENTS] [-p PROJECT]",
+		ShortHelp:  "Add a webhook receiving a project's events",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if *webhookURL == "" && len(args) == 1 {
+				*webhookURL = args[0]
+			}
+			if *webhookURL == "" {
+				return errors.New("a webhook --url is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var created webhook
+			request := map[string]any{"url": *webhookURL, "events": splitList(*events)}
+			if err := NewClient(cfg.URL, project.APIKey).Post(ctx, "/projects/"+project.ID+"/webhooks", request, &created); err != nil {
+				return err
+			}
+
+			return render(opts.output, created,
+				[]string{"ID", "URL", "EVENTS"},
+				[][]string{{created.ID, created.URL, strings.Join(created.Events, ",")}},
+			)
+		},
+	}
+}
+
+func newWebhooksLsCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra webhooks ls", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "ls",
+		ShortUsage: "orra webhooks ls [-p PROJECT]",
+		ShortHelp:  "List a project's webhooks",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var webhooks []webhook
+			if err := NewClient(cfg.URL, project.APIKey).Get(ctx, "/projects/"+project.ID+"/webhooks", &webhooks); err != nil {
+				return err
+			}
+
+			rows := make([][]string, 0, len(webhooks))
+			for _, webhook := range webhooks {
+				rows = append(rows, []string{webhook.ID, webhook.URL, strings.Join(webhook.Events, ","), ago(webhook.CreatedAt)})
+			}
+
+			return render(opts.output, webhooks, []string{"ID", "URL", "EVENTS", "CREATED"}, rows)
+		},
+	}
+}
+
+func newWebhooksRmCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra webhooks rm", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "rm",
+		ShortUsage: "orra webhooks rm [-p PROJECT] WEBHOOK_ID",
+		ShortHelp:  "Remove one of a project's webhooks",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a WEBHOOK_ID is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			path := fmt.Sprintf("/projects/%s/webhooks/%s", project.ID, url.PathEscape(args[0]))
+			if err := NewClient(cfg.URL, project.APIKey).Delete(ctx, path, nil); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed webhook %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newWebhooksSecretCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra webhooks secret", flag.ExitOnError)
+	opts.register(fs, true)
+	rotate := fs.Bool("rotate", false, "replace the secret with a new one")
+
+	return &ffcli.Command{
+		Name:       "secret",
+		ShortUsage: "orra webhooks secret [--rotate] [-p PROJECT]",
+		ShortHelp:  "Show the secret a project's webhook payloads are signed with",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var secret struct {
+				Secret string `json:"secret"`
+			}
+			client := NewClient(cfg.URL, project.APIKey)
+			if *rotate {
+				err = client.Post(ctx, "/projects/"+project.ID+"/webhook-secret/rotate", nil, &secret)
+			} else {
+				err = client.Get(ctx, "/projects/"+project.ID+"/webhook-secret", &secret)
+			}
+			if err != nil {
+				return err
+			}
+
+			return render(opts.output, secret, []string{"SECRET"}, [][]string{{secret.Secret}})
+		},
+	}
+}
+
+func newWebhooksDeadLettersCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra webhooks dead-letters", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "dead-letters",
+		ShortUsage: "orra webhooks dead-letters [-p PROJECT]",
+		ShortHelp:  "List webhook deliveries that failed after every retry",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var deliveries []webhookDelivery
+			if err := NewClient(cfg.URL, project.APIKey).Get(ctx, "/projects/"+project.ID+"/dead-letters", &deliveries); err != nil {
+				return err
+			}
+
+			rows := make([][]string, 0, len(deliveries))
+			for _, delivery := range deliveries {
+				deadAt := "-"
+				if delivery.DeadAt != nil {
+					deadAt = ago(*delivery.DeadAt)
+				}
+				rows = append(rows, []string{
+					delivery.ID,
+					delivery.EventType,
+					delivery.OrchestrationID,
+					delivery.URL,
+					fmt.Sprint(delivery.Attempts),
+					truncate(delivery.LastError, 40),
+					deadAt,
+				})
+			}
+
+			return render(opts.output, deliveries, []string{"ID", "EVENT", "ORCHESTRATION", "URL", "ATTEMPTS", "LAST ERROR", "FAILED"}, rows)
+		},
+	}
+}
+
+func newWebhooksRedriveCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra webhooks redrive", flag.ExitOnError)
+	opts.register(fs, true)
+	all := fs.Bool("all", false, "redrive every dead-lettered delivery")
+
+	return &ffcli.Command{
+		Name:       "redrive",
+		ShortUsage: "orra webhooks redrive [-p PROJECT] --all | DELIVERY_ID...",
+		ShortHelp:  "Retry dead-lettered webhook deliveries",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 && !*all {
+				return errors.New("either --all or at least one DELIVERY_ID is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var redriven []webhookDelivery
+			request := map[string][]string{"ids": args}
+			if err := NewClient(cfg.URL, project.APIKey).Post(ctx, "/projects/"+project.ID+"/dead-letters/redrive", request, &redriven); err != nil {
+				return err
+			}
+
+			fmt.Printf("Redriving %d webhook deliveries\n", len(redriven))
+			return nil
+		},
+	}
+}
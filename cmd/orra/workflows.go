@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type workflow struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Params      []string        `json:"params"`
+	Plan        json.RawMessage `json:"plan"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+func newWorkflowsCmd() *ffcli.Command {
+	return &ffcli.Command{
+		Name:       "workflows",
+		ShortUsage: "orra workflows COMMAND",
+		ShortHelp:  "Add and manage workflows with fixed plans for a project",
+		FlagSet:    flag.NewFlagSet("orra workflows", flag.ExitOnError),
+		Subcommands: []*ffcli.Command{
+			newWorkflowsAddCmd(),
+			newWorkflowsLsCmd(),
+			newWorkflowsRmCmd(),
+		},
+		Exec: func(context.Context, []string) error {
+			return flag.ErrHelp
+		},
+	}
+}
+
+func newWorkflowsAddCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra workflows add", flag.ExitOnError)
+	opts.register(fs, true)
+	planFile := fs.String("plan", "", "JSON file with the workflow's plan")
+	description := fs.String("description", "", "what the workflow does")
+
+	return &ffcli.Command{
+		Name:       "add",
+		ShortUsage: "orra workflows add --plan FILE [--description TEXT] [-p PROJECT] NAME",
+		ShortHelp:  "Add or replace a workflow running a fixed plan",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a workflow NAME is required")
+			}
+			if *planFile == "" {
+				return errors.New("a --plan file is required")
+			}
+
+			plan, err := os.ReadFile(*planFile)
+			if err != nil {
+				return fmt.Errorf("failed to read plan: %w", err)
+			}
+			if !json.Valid(plan) {
+				return fmt.Errorf("plan %s is not valid JSON", *planFile)
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var saved workflow
+			request := map[string]any{"description": *description, "plan": json.RawMessage(plan)}
+			path := fmt.Sprintf("/projects/%s/workflows/%s", project.ID, url.PathEscape(args[0]))
+			if err := NewClient(cfg.URL, project.APIKey).Put(ctx, path, request, &saved); err != nil {
+				return err
+			}
+
+			return render(opts.output, saved,
+				[]string{"NAME", "PARAMS", "DESCRIPTION"},
+				[][]string{{saved.Name, strings.Join(saved.Params, ","), saved.Description}},
+			)
+		},
+	}
+}
+
+func newWorkflowsLsCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra workflows ls", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "ls",
+		ShortUsage: "orra workflows ls [-p PROJECT]",
+		ShortHelp:  "List a project's workflows",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, _ []string) error {
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			var workflows []workflow
+			if err := NewClient(cfg.URL, project.APIKey).Get(ctx, "/projects/"+project.ID+"/workflows", &workflows); err != nil {
+				return err
+			}
+
+			rows := make([][]string, 0, len(workflows))
+			for _, workflow := range workflows {
+				rows = append(rows, []string{workflow.Name, strings.Join(workflow.Params, ","), truncate(workflow.Description, 40), ago(workflow.UpdatedAt)})
+			}
+
+			return render(opts.output, workflows, []string{"NAME", "PARAMS", "DESCRIPTION", "UPDATED"}, rows)
+		},
+	}
+}
+
+func newWorkflowsRmCmd() *ffcli.Command {
+	var opts options
+	fs := flag.NewFlagSet("orra workflows rm", flag.ExitOnError)
+	opts.register(fs, true)
+
+	return &ffcli.Command{
+		Name:       "rm",
+		ShortUsage: "orra workflows rm [-p PROJECT] NAME",
+		ShortHelp:  "Remove one of a project's workflows",
+		FlagSet:    fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("a workflow NAME is required")
+			}
+
+			cfg, project, err := opts.loadProject()
+			if err != nil {
+				return err
+			}
+
+			path := fmt.Sprintf("/projects/%s/workflows/%s", project.ID, url.PathEscape(args[0]))
+			if err := NewClient(cfg.URL, project.APIKey).Delete(ctx, path, nil); err != nil {
+				return err
+			}
+
+			fmt.Printf("Removed workflow %s\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleProject Role = "project"
+)
+
+const adminKeyPrefix = "sk-orra-admin-"
+
+// AdminCredential is the admin key's hash, only the configured or bootstrapped key itself grants admin access.
+type AdminCredential struct {
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BootstrapAdmin sets up the admin credential. A configured admin key always takes precedence, otherwise the
+// credential stored on a previous run is used. On the very first run a key is generated and returned, it is
+// the only time it is available.
+func (p *ControlPlane) BootstrapAdmin(configuredKey string) (string, error) {
+	if configuredKey = strings.TrimSpace(configuredKey); configuredKey != "" {
+		p.setAdminCredential(&AdminCredential{Hash: hashAPIKey(configuredKey), CreatedAt: time.Now().UTC()})
+		return "", nil
+	}
+
+	credential, err := p.store.GetAdminCredential()
+	if err == nil {
+		p.setAdminCredential(credential)
+		return "", nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return "", fmt.Errorf("failed to load admin credential: %w", err)
+	}
+
+	secret, err := generateAPIKey()
+	if err != nil {
+		return "", err
+	}
+	secret = adminKeyPrefix + strings.TrimPrefix(secret, apiKeyPrefix)
+
+	credential = &AdminCredential{Hash: hashAPIKey(secret), CreatedAt: time.Now().UTC()}
+	if err := p.store.SaveAdminCredential(credential); err != nil {
+		return "", fmt.Errorf("failed to save admin credential: %w", err)
+	}
+	p.setAdminCredential(credential)
+
+	return secret, nil
+}
+
+// IsAdminKey reports whether the key is the admin credential.
+func (p *ControlPlane) IsAdminKey(key string) bool {
+	p.adminMu.RLock()
+	defer p.adminMu.RUnlock()
+
+	if p.admin == nil || key == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashAPIKey(key)), []byte(p.admin.Hash)) == 1
+}
+
+func (p *ControlPlane) setAdminCredential(credential *AdminCredential) {
+	p.adminMu.Lock()
+	defer p.adminMu.Unlock()
+	p.admin = credential
+}
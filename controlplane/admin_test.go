@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestBootstrapAdminGeneratesKeyOnlyOnFirstRun(t *testing.T) {
+	plane, store := newTestPlane(t, nil)
+
+	adminKey, err := plane.BootstrapAdmin("")
+	if err != nil || adminKey == "" {
+		t.Fatalf("expected an admin key to be generated, got %q, err %v", adminKey, err)
+	}
+	if !plane.IsAdminKey(adminKey) || plane.IsAdminKey("") || plane.IsAdminKey("guess") {
+		t.Fatalf("admin key not recognised correctly")
+	}
+
+	restarted := NewControlPlane(nil, store)
+	again, err := restarted.BootstrapAdmin("")
+	if err != nil || again != "" {
+		t.Fatalf("expected the stored admin credential to be reused, got %q, err %v", again, err)
+	}
+	if !restarted.IsAdminKey(adminKey) {
+		t.Errorf("expected the bootstrapped admin key to survive restarts")
+	}
+
+	configured := NewControlPlane(nil, store)
+	if _, err := configured.BootstrapAdmin("configured-admin-key"); err != nil {
+		t.Fatalf("failed to bootstrap configured admin key: %v", err)
+	}
+	if !configured.IsAdminKey("configured-admin-key") || configured.IsAdminKey(adminKey) {
+		t.Errorf("expected the configured admin key to take precedence")
+	}
+}
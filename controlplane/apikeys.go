@@ -0,0 +1,331 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Scope string
+
+const (
+	ScopeOrchestrate     Scope = "orchestrate"
+	ScopeRegisterService Scope = "register-service"
+	ScopeReadOnly        Scope = "read-only"
+	// ScopeManage allows managing the project itself, e.g. its webhooks and API keys.
+	ScopeManage Scope = "manage"
+)
+
+var AllScopes = []Scope{ScopeOrchestrate, ScopeRegisterService, ScopeReadOnly, ScopeManage}
+
+const (
+	DefaultAPIKeyName = "default"
+	apiKeyPrefix      = "sk-orra-"
+)
+
+type APIKey struct {
+	ID         string     `json:"id"`
+	ProjectID  string     `json:"projectId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreatedAPIKey is only returned when a key is created, it is the one time the key itself is available.
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+func ParseScope(val string) (Scope, error) {
+	scope := Scope(strings.ToLower(strings.TrimSpace(val)))
+	if !slices.Contains(AllScopes, scope) {
+		return "", fmt.Errorf("invalid scope: %s", val)
+	}
+	return scope, nil
+}
+
+// readScopes are the scopes that also grant reading, managing a project or orchestrating needs to see the
+// results while a key only registering services never reads anything back.
+var readScopes = []Scope{ScopeReadOnly, ScopeOrchestrate, ScopeManage}
+
+// Allows reports whether the key grants the scope.
+func (k *APIKey) Allows(scope Scope) bool {
+	if scope == ScopeReadOnly {
+		return slices.ContainsFunc(k.Scopes, func(granted Scope) bool { return slices.Contains(readScopes, granted) })
+	}
+	return slices.Contains(k.Scopes, scope)
+}
+
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+func (k *APIKey) clone() *APIKey {
+	out := *k
+	out.Scopes = slices.Clone(k.Scopes)
+	return &out
+}
+
+// LoadAPIKeys builds the index of active API keys.
+func (p *ControlPlane) LoadAPIKeys() error {
+	p.projectsMu.RLock()
+	defer p.projectsMu.RUnlock()
+
+	projects, err := p.store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("failed to load projects: %w", err)
+	}
+
+	index := make(map[string]*APIKey)
+	for _, project := range projects {
+		keys, err := p.store.ListAPIKeys(project.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load API keys for project %s: %w", project.ID, err)
+		}
+		for _, key := range keys {
+			if !key.Revoked() {
+				index[key.Hash] = key
+			}
+		}
+	}
+
+	p.apiKeysMu.Lock()
+	p.apiKeys = index
+	p.apiKeysMu.Unlock()
+
+	p.Logger.Debug().Int("APIKeys", len(index)).Msg("Loaded API keys")
+	return nil
+}
+
+// MigrateProjects upgrades projects stored before multiple API keys and webhooks were supported, their
+// single key becomes a default key with every scope, their webhook one subscribed to the default events
+// and they get a webhook secret.
+func (p *ControlPlane) MigrateProjects() error {
+	p.projectsMu.Lock()
+	defer p.projectsMu.Unlock()
+
+	projects, err := p.store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("failed to load projects: %w", err)
+	}
+
+	for _, project := range projects {
+		if project.APIKey != "" {
+			if err := p.migrateProjectAPIKey(project); err != nil {
+				return err
+			}
+		}
+		if project.Webhook != "" {
+			if err := p.migrateProjectWebhook(project); err != nil {
+				return err
+			}
+		}
+		if project.WebhookSecret == "" {
+			if err := p.migrateProjectWebhookSecret(project); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrateProjectAPIKey callers should hold the projectsMu lock.
+func (p *ControlPlane) migrateProjectAPIKey(project *Project) error {
+	key := newAPIKey(project.ID, DefaultAPIKeyName, project.APIKey, AllScopes)
+	if err := p.store.SaveAPIKey(key); err != nil {
+		return fmt.Errorf("failed to migrate API key for project %s: %w", project.ID, err)
+	}
+
+	project.APIKey = ""
+	if err := p.store.SaveProject(project); err != nil {
+		return fmt.Errorf("failed to migrate API key for project %s: %w", project.ID, err)
+	}
+
+	p.Logger.Info().Str("ProjectID", project.ID).Msg("Migrated project API key")
+	return nil
+}
+
+// CreateAPIKey generates a new key for the project, a key created without scopes is granted every scope.
+func (p *ControlPlane) CreateAPIKey(projectID, name string, scopes []Scope) (*CreatedAPIKey, error) {
+	if len(scopes) == 0 {
+		scopes = AllScopes
+	}
+
+	secret, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	key := newAPIKey(projectID, strings.TrimSpace(name), secret, slices.Compact(scopes))
+	if err := p.store.SaveAPIKey(key); err != nil {
+		return nil, fmt.Errorf("failed to save API key for project %s: %w", projectID, err)
+	}
+
+	p.apiKeysMu.Lock()
+	p.apiKeys[key.Hash] = key.clone()
+	p.apiKeysMu.Unlock()
+
+	p.Logger.Debug().
+		Str("ProjectID", projectID).
+		Str("APIKeyID", key.ID).
+		Msg("Created API key")
+
+	return &CreatedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+// ListAPIKeys returns the project's keys, including revoked ones, oldest first.
+func (p *ControlPlane) ListAPIKeys(projectID string) ([]*APIKey, error) {
+	keys, err := p.store.ListAPIKeys(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API keys for project %s: %w", projectID, err)
+	}
+
+	p.apiKeysMu.RLock()
+	for i, key := range keys {
+		// Last used timestamps are only persisted periodically, the index has the latest
+		if active, ok := p.apiKeys[key.Hash]; ok {
+			keys[i] = active.clone()
+		}
+	}
+	p.apiKeysMu.RUnlock()
+
+	slices.SortFunc(keys, func(a, b *APIKey) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return keys, nil
+}
+
+// RevokeAPIKey stops the key from authenticating any further requests.
+func (p *ControlPlane) RevokeAPIKey(projectID, keyID string) (*APIKey, error) {
+	keys, err := p.store.ListAPIKeys(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API keys for project %s: %w", projectID, err)
+	}
+
+	idx := slices.IndexFunc(keys, func(key *APIKey) bool { return key.ID == keyID })
+	if idx < 0 {
+		return nil, fmt.Errorf("API key %s for project %s: %w", keyID, projectID, ErrNotFound)
+	}
+
+	key := keys[idx]
+	if key.Revoked() {
+		return key, nil
+	}
+
+	p.apiKeysMu.Lock()
+	defer p.apiKeysMu.Unlock()
+
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	if active, ok := p.apiKeys[key.Hash]; ok {
+		key.LastUsedAt = active.LastUsedAt
+	}
+	if err := p.store.SaveAPIKey(key); err != nil {
+		return nil, fmt.Errorf("failed to revoke API key %s: %w", keyID, err)
+	}
+	delete(p.apiKeys, key.Hash)
+
+	p.Logger.Info().
+		Str("ProjectID", projectID).
+		Str("APIKeyID", key.ID).
+		Msg("Revoked API key")
+
+	return key, nil
+}
+
+// AuthenticateAPIKey returns the project and key matching an API key, as long as the key has not been revoked.
+func (p *ControlPlane) AuthenticateAPIKey(secret string) (*Project, *APIKey, error) {
+	hash := hashAPIKey(secret)
+
+	p.apiKeysMu.RLock()
+	key, exists := p.apiKeys[hash]
+	if exists {
+		key = key.clone()
+	}
+	p.apiKeysMu.RUnlock()
+
+	if !exists {
+		return nil, nil, fmt.Errorf("no project found with the given API key")
+	}
+
+	p.projectsMu.RLock()
+	project, err := p.store.GetProject(key.ProjectID)
+	p.projectsMu.RUnlock()
+	if err != nil {
+		return nil, nil, fmt.Errorf("no project found with the given API key: %w", err)
+	}
+
+	p.touchAPIKey(key)
+	return project, key, nil
+}
+
+// touchAPIKey records the key was used, persisting it at most once per APIKeyLastUsedResolution.
+func (p *ControlPlane) touchAPIKey(key *APIKey) {
+	now := time.Now().UTC()
+	if key.LastUsedAt != nil && now.Sub(*key.LastUsedAt) < APIKeyLastUsedResolution {
+		return
+	}
+
+	p.apiKeysMu.Lock()
+	defer p.apiKeysMu.Unlock()
+
+	active, exists := p.apiKeys[key.Hash]
+	if !exists || (active.LastUsedAt != nil && now.Sub(*active.LastUsedAt) < APIKeyLastUsedResolution) {
+		return
+	}
+
+	active.LastUsedAt = &now
+	if err := p.store.SaveAPIKey(active); err != nil {
+		p.Logger.Error().Err(err).Str("APIKeyID", active.ID).Msg("Failed to save API key usage")
+	}
+}
+
+// removeProjectAPIKeys drops a deleted project's keys from the index.
+func (p *ControlPlane) removeProjectAPIKeys(projectID string) {
+	p.apiKeysMu.Lock()
+	defer p.apiKeysMu.Unlock()
+
+	for hash, key := range p.apiKeys {
+		if key.ProjectID == projectID {
+			delete(p.apiKeys, hash)
+		}
+	}
+}
+
+func newAPIKey(projectID, name, secret string, scopes []Scope) *APIKey {
+	return &APIKey{
+		ID:        uuid.New().String(),
+		ProjectID: projectID,
+		Name:      name,
+		Prefix:    secret[:min(len(secret), len(apiKeyPrefix)+4)],
+		Hash:      hashAPIKey(secret),
+		Scopes:    slices.Clone(scopes),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func generateAPIKey() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(secret), nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAPIKeysAuthenticateUntilRevoked(t *testing.T) {
+	plane, store := newTestPlane(t, nil)
+	_ = store.SaveProject(&Project{ID: "legacy", APIKey: "legacy-key"})
+	if err := plane.MigrateProjects(); err != nil {
+		t.Fatalf("failed to migrate projects: %v", err)
+	}
+	if err := plane.LoadAPIKeys(); err != nil {
+		t.Fatalf("failed to load API keys: %v", err)
+	}
+
+	// Keys issued before named keys existed keep working with every scope
+	project, key, err := plane.AuthenticateAPIKey("legacy-key")
+	if err != nil || project.ID != "legacy" || !key.Allows(ScopeManage) {
+		t.Fatalf("got project %+v, key %+v, err %v", project, key, err)
+	}
+	if migrated, _ := store.GetProject("legacy"); migrated.APIKey != "" {
+		t.Errorf("expected the legacy key to be removed from the stored project")
+	}
+
+	created, err := plane.CreateAPIKey("legacy", "ci", []Scope{ScopeOrchestrate})
+	if err != nil {
+		t.Fatalf("failed to create API key: %v", err)
+	}
+
+	_, key, err = plane.AuthenticateAPIKey(created.Key)
+	if err != nil {
+		t.Fatalf("failed to authenticate created key: %v", err)
+	}
+	if !key.Allows(ScopeOrchestrate) || !key.Allows(ScopeReadOnly) || key.Allows(ScopeRegisterService) {
+		t.Errorf("unexpected scopes for key: %+v", key.Scopes)
+	}
+	keys, _ := plane.ListAPIKeys("legacy")
+	if len(keys) != 2 || keys[1].ID != created.ID || keys[1].LastUsedAt == nil {
+		t.Errorf("expected the key's last use to be recorded, got %+v", keys)
+	}
+
+	if _, err := plane.RevokeAPIKey("legacy", created.ID); err != nil {
+		t.Fatalf("failed to revoke API key: %v", err)
+	}
+	if _, _, err := plane.AuthenticateAPIKey(created.Key); err == nil {
+		t.Errorf("expected revoked key to be rejected")
+	}
+
+	// Reloading the index must not resurrect revoked keys
+	if err := plane.LoadAPIKeys(); err != nil {
+		t.Fatalf("failed to reload API keys: %v", err)
+	}
+	if _, _, err := plane.AuthenticateAPIKey(created.Key); err == nil {
+		t.Errorf("expected revoked key to be rejected after reloading")
+	}
+	if _, _, err := plane.AuthenticateAPIKey("legacy-key"); err != nil {
+		t.Errorf("expected migrated key to survive reloading: %v", err)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	testCases := []struct {
+		granted []Scope
+		allowed []Scope
+	}{
+		{[]Scope{ScopeReadOnly}, []Scope{ScopeReadOnly}},
+		{[]Scope{ScopeOrchestrate}, []Scope{ScopeOrchestrate, ScopeReadOnly}},
+		{[]Scope{ScopeManage}, []Scope{ScopeManage, ScopeReadOnly}},
+		{[]Scope{ScopeRegisterService}, []Scope{ScopeRegisterService}},
+		{[]Scope{ScopeRegisterService, ScopeReadOnly}, []Scope{ScopeRegisterService, ScopeReadOnly}},
+	}
+
+	for _, tc := range testCases {
+		key := &APIKey{Scopes: tc.granted}
+		for _, scope := range AllScopes {
+			if want := slices.Contains(tc.allowed, scope); key.Allows(scope) != want {
+				t.Errorf("key with scopes %v: got Allows(%s) %v, want %v", tc.granted, scope, !want, want)
+			}
+		}
+	}
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gilcrest/diygoapi/errs"
@@ -38,10 +42,39 @@ func NewApp(cfg Config, args []string) (*App, error) {
 }
 
 func (app *App) configureRoutes() *App {
-	app.Router.HandleFunc("/register/project", app.RegisterProject).Methods("POST")
-	app.Router.HandleFunc("/register/service", app.APIKeyMiddleware(app.RegisterService)).Methods("POST")
-	app.Router.HandleFunc("/orchestrations", app.APIKeyMiddleware(app.OrchestrationsHandler)).Methods("POST")
-	app.Router.HandleFunc("/register/agent", app.APIKeyMiddleware(app.RegisterAgent)).Methods("POST")
+	app.Router.HandleFunc("/version", app.Version).Methods("GET")
+	app.Router.HandleFunc("/register/project", app.AdminMiddleware(app.RegisterProject)).Methods("POST")
+	app.Router.HandleFunc("/projects", app.AdminOrAPIKeyMiddleware(ScopeReadOnly, app.ListProjects)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}", app.AdminOrAPIKeyMiddleware(ScopeReadOnly, app.GetProject)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}", app.AdminOrAPIKeyMiddleware(ScopeManage, app.UpdateProject)).Methods("PATCH")
+	app.Router.HandleFunc("/projects/{id}", app.AdminOrAPIKeyMiddleware(ScopeManage, app.DeleteProject)).Methods("DELETE")
+	app.Router.HandleFunc("/projects/{id}/webhooks", app.AdminOrAPIKeyMiddleware(ScopeReadOnly, app.ListWebhooks)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}/webhooks", app.AdminOrAPIKeyMiddleware(ScopeManage, app.AddWebhook)).Methods("POST")
+	app.Router.HandleFunc("/projects/{id}/webhooks/{webhookId}", app.AdminOrAPIKeyMiddleware(ScopeManage, app.UpdateWebhook)).Methods("PUT")
+	app.Router.HandleFunc("/projects/{id}/webhooks/{webhookId}", app.AdminOrAPIKeyMiddleware(ScopeManage, app.RemoveWebhook)).Methods("DELETE")
+	app.Router.HandleFunc("/projects/{id}/webhook-secret", app.AdminOrAPIKeyMiddleware(ScopeManage, app.GetWebhookSecret)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}/webhook-secret/rotate", app.AdminOrAPIKeyMiddleware(ScopeManage, app.RotateWebhookSecret)).Methods("POST")
+	app.Router.HandleFunc("/projects/{id}/dead-letters", app.AdminOrAPIKeyMiddleware(ScopeReadOnly, app.ListDeadLetters)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}/dead-letters/redrive", app.AdminOrAPIKeyMiddleware(ScopeManage, app.RedriveDeadLetters)).Methods("POST")
+	app.Router.HandleFunc("/projects/{id}/dead-letters/{deliveryId}/redrive", app.AdminOrAPIKeyMiddleware(ScopeManage, app.RedriveDeadLetter)).Methods("POST")
+	app.Router.HandleFunc("/projects/{id}/workflows", app.AdminOrAPIKeyMiddleware(ScopeReadOnly, app.ListWorkflows)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}/workflows/{name}", app.AdminOrAPIKeyMiddleware(ScopeReadOnly, app.GetWorkflow)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}/workflows/{name}", app.AdminOrAPIKeyMiddleware(ScopeManage, app.SaveWorkflow)).Methods("PUT")
+	app.Router.HandleFunc("/projects/{id}/workflows/{name}", app.AdminOrAPIKeyMiddleware(ScopeManage, app.DeleteWorkflow)).Methods("DELETE")
+	app.Router.HandleFunc("/projects/{id}/api-keys", app.AdminOrAPIKeyMiddleware(ScopeManage, app.ListAPIKeys)).Methods("GET")
+	app.Router.HandleFunc("/projects/{id}/api-keys", app.AdminOrAPIKeyMiddleware(ScopeManage, app.CreateAPIKey)).Methods("POST")
+	app.Router.HandleFunc("/projects/{id}/api-keys/{keyId}", app.AdminOrAPIKeyMiddleware(ScopeManage, app.RevokeAPIKey)).Methods("DELETE")
+	app.Router.HandleFunc("/register/service", app.APIKeyMiddleware(ScopeRegisterService, app.RegisterService)).Methods("POST")
+	app.Router.HandleFunc("/orchestrations", app.APIKeyMiddleware(ScopeOrchestrate, app.OrchestrationsHandler)).Methods("POST")
+	app.Router.HandleFunc("/orchestrations", app.APIKeyMiddleware(ScopeReadOnly, app.ListOrchestrations)).Methods("GET")
+	app.Router.HandleFunc("/orchestrations/plan", app.APIKeyMiddleware(ScopeOrchestrate, app.PlanOrchestration)).Methods("POST")
+	app.Router.HandleFunc("/orchestrations/{id}", app.APIKeyMiddleware(ScopeReadOnly, app.InspectOrchestration)).Methods("GET")
+	app.Router.HandleFunc("/orchestrations/{id}/logs", app.APIKeyMiddleware(ScopeReadOnly, app.OrchestrationLogs)).Methods("GET")
+	app.Router.HandleFunc("/orchestrations/{id}/deliveries", app.APIKeyMiddleware(ScopeReadOnly, app.ListOrchestrationDeliveries)).Methods("GET")
+	app.Router.HandleFunc("/orchestrations/{id}/deliveries/redeliver", app.APIKeyMiddleware(ScopeOrchestrate, app.RedeliverOrchestration)).Methods("POST")
+	app.Router.HandleFunc("/orchestrations/{id}/logs/stream", app.APIKeyMiddleware(ScopeReadOnly, app.StreamOrchestrationLogs)).Methods("GET")
+	app.Router.HandleFunc("/services/{id}/versions", app.APIKeyMiddleware(ScopeReadOnly, app.ListServiceVersions)).Methods("GET")
+	app.Router.HandleFunc("/register/agent", app.APIKeyMiddleware(ScopeRegisterService, app.RegisterAgent)).Methods("POST")
 	app.Router.HandleFunc("/ws", app.HandleWebSocket)
 	return app
 }
@@ -118,6 +151,10 @@ func (app *App) Run() {
 	app.Logger.Debug().Msg("http: All connections drained")
 }
 
+func (app *App) Version(w http.ResponseWriter, _ *http.Request) {
+	app.writeJSON(w, http.StatusOK, map[string]string{"version": app.Cfg.Version})
+}
+
 func (app *App) RegisterProject(w http.ResponseWriter, r *http.Request) {
 	var project Project
 	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
@@ -125,10 +162,19 @@ func (app *App) RegisterProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if project.Webhook != "" {
+		if err := validateWebhook(project.Webhook); err != nil {
+			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+			return
+		}
+	}
+
 	project.ID = uuid.New().String()
-	project.APIKey = uuid.New().String()
 
-	app.Plane.projects[project.ID] = &project
+	if err := app.Plane.RegisterProject(&project); err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
+		return
+	}
 
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(project); err != nil {
@@ -137,14 +183,388 @@ func (app *App) RegisterProject(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (app *App) RegisterServiceOrAgent(w http.ResponseWriter, r *http.Request, serviceType ServiceType) {
-	apiKey := r.Context().Value("api_key").(string)
-	project, err := app.Plane.GetProjectByApiKey(apiKey)
+func (app *App) ListProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Context().Value("role") != RoleAdmin {
+		project := r.Context().Value("project").(*Project)
+		app.writeJSON(w, http.StatusOK, []*Project{project})
+		return
+	}
+
+	projects, err := app.Plane.ListProjects()
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
+		return
+	}
+	if projects == nil {
+		projects = []*Project{}
+	}
+
+	app.writeJSON(w, http.StatusOK, projects)
+}
+
+func (app *App) GetProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := app.Plane.GetProjectDetails(project.ID)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, details)
+}
+
+func (app *App) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	var update ProjectUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, errs.Code(JSONMarshalingFail), err))
+		return
+	}
+
+	updated, err := app.Plane.UpdateProject(project.ID, update)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, updated)
+}
+
+func (app *App) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks := project.Webhooks
+	if webhooks == nil {
+		webhooks = []*Webhook{}
+	}
+
+	app.writeJSON(w, http.StatusOK, webhooks)
+}
+
+func (app *App) AddWebhook(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	url, events, err := decodeWebhookRequest(r, true)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+
+	webhook, err := app.Plane.AddWebhook(project.ID, url, events)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusCreated, webhook)
+}
+
+func (app *App) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	url, events, err := decodeWebhookRequest(r, false)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+
+	webhook, err := app.Plane.UpdateWebhook(project.ID, mux.Vars(r)["webhookId"], url, events)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, webhook)
+}
+
+func (app *App) RemoveWebhook(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.Plane.RemoveWebhook(project.ID, mux.Vars(r)["webhookId"]); err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *App) GetWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := app.Plane.GetWebhookSecret(project.ID)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, map[string]string{"secret": secret})
+}
+
+func (app *App) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := app.Plane.RotateWebhookSecret(project.ID)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, map[string]string{"secret": secret})
+}
+
+func (app *App) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	deadLetters, err := app.Plane.ListDeadLetters(project.ID)
 	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, deadLetters)
+}
+
+// RedriveDeadLetters redrives the dead-lettered deliveries listed in the request's ids, or all of them without a body.
+func (app *App) RedriveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
 		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
 		return
 	}
 
+	app.redriveDeadLetters(w, project.ID, request.IDs...)
+}
+
+func (app *App) RedriveDeadLetter(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	app.redriveDeadLetters(w, project.ID, mux.Vars(r)["deliveryId"])
+}
+
+func (app *App) redriveDeadLetters(w http.ResponseWriter, projectID string, deliveryIDs ...string) {
+	redriven, err := app.Plane.RedriveDeadLetters(projectID, deliveryIDs...)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusAccepted, redriven)
+}
+
+func (app *App) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.Plane.DeleteProject(project.ID); err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *App) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	workflows, err := app.Plane.ListWorkflows(project.ID)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, workflows)
+}
+
+func (app *App) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	workflow, err := app.Plane.GetWorkflow(project.ID, mux.Vars(r)["name"])
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, workflow)
+}
+
+// SaveWorkflow registers or replaces a named workflow, the request holds its plan and an optional description.
+func (app *App) SaveWorkflow(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Description string              `json:"description"`
+		Plan        *ServiceCallingPlan `json:"plan"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, errs.Code(JSONMarshalingFail), err))
+		return
+	}
+
+	workflow, err := app.Plane.SaveWorkflow(project.ID, mux.Vars(r)["name"], request.Description, request.Plan)
+	if errors.Is(err, ErrInvalidWorkflow) {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, workflow)
+}
+
+func (app *App) DeleteWorkflow(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.Plane.DeleteWorkflow(project.ID, mux.Vars(r)["name"]); err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *App) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := app.Plane.ListAPIKeys(project.ID)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
+		return
+	}
+	if keys == nil {
+		keys = []*APIKey{}
+	}
+
+	app.writeJSON(w, http.StatusOK, keys)
+}
+
+func (app *App) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, errs.Code(JSONMarshalingFail), err))
+		return
+	}
+
+	if strings.TrimSpace(request.Name) == "" {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, "API key name is required"))
+		return
+	}
+
+	scopes := make([]Scope, 0, len(request.Scopes))
+	for _, val := range request.Scopes {
+		scope, err := ParseScope(val)
+		if err != nil {
+			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+
+	key, err := app.Plane.CreateAPIKey(project.ID, request.Name, scopes)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusCreated, key)
+}
+
+func (app *App) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	project, ok := app.authorizedProject(w, r)
+	if !ok {
+		return
+	}
+
+	key, err := app.Plane.RevokeAPIKey(project.ID, mux.Vars(r)["keyId"])
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, key)
+}
+
+// authorizedProject returns the project addressed by the request's path, as long as the request is
+// made by an admin or with an API key belonging to the project.
+func (app *App) authorizedProject(w http.ResponseWriter, r *http.Request) (*Project, bool) {
+	project := r.Context().Value("project").(*Project)
+
+	if project.ID != mux.Vars(r)["id"] {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, "API key does not grant access to the project"))
+		return nil, false
+	}
+
+	return project, true
+}
+
+func (app *App) RegisterServiceOrAgent(w http.ResponseWriter, r *http.Request, serviceType ServiceType) {
+	project := r.Context().Value("project").(*Project)
+
 	var service ServiceInfo
 	if err := json.NewDecoder(r.Body).Decode(&service); err != nil {
 		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, errs.Code(JSONMarshalingFail), err))
@@ -154,16 +574,28 @@ func (app *App) RegisterServiceOrAgent(w http.ResponseWriter, r *http.Request, s
 	service.ProjectID = project.ID
 	service.Type = serviceType
 
-	if err := app.Plane.RegisterOrUpdateService(&service); err != nil {
+	force := r.URL.Query().Get("force") == "true"
+	changes, err := app.Plane.RegisterOrUpdateService(&service, app.Cfg.StrictSchemaUpdates && !force)
+	if errors.Is(err, ErrInvalidServiceSchema) || errors.Is(err, ErrBreakingSchemaChange) {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+	if err != nil {
 		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(map[string]any{
-		"id":     service.ID,
-		"name":   service.Name,
-		"status": Registered,
-	}); err != nil {
+	response := map[string]any{
+		"id":      service.ID,
+		"name":    service.Name,
+		"status":  Registered,
+		"version": service.Version,
+	}
+	if breaking := breakingChanges(changes); len(breaking) > 0 {
+		response["breakingChanges"] = breaking
+	}
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
 		return
 	}
@@ -177,35 +609,39 @@ func (app *App) RegisterAgent(w http.ResponseWriter, r *http.Request) {
 	app.RegisterServiceOrAgent(w, r, Agent)
 }
 
-func (app *App) OrchestrationsHandler(w http.ResponseWriter, r *http.Request) {
-	apiKey := r.Context().Value("api_key").(string)
-	project, err := app.Plane.GetProjectByApiKey(apiKey)
+func (app *App) ListServiceVersions(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	versions, err := app.Plane.ServiceVersions(project.ID, mux.Vars(r)["id"])
 	if err != nil {
-		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
 		return
 	}
 
-	var orchestration Orchestration
-	if err := json.NewDecoder(r.Body).Decode(&orchestration); err != nil {
+	app.writeJSON(w, http.StatusOK, versions)
+}
+
+func (app *App) OrchestrationsHandler(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	orchestration, err := decodeOrchestrationRequest(r, project)
+	if err != nil {
 		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, errs.Code(JSONMarshalingFail), err))
 		return
 	}
 
-	orchestration.ID = uuid.New().String()
-	orchestration.Status = Pending
-	orchestration.ProjectID = project.ID
-
-	app.Plane.PrepareOrchestration(&orchestration)
+	app.Plane.PrepareOrchestration(orchestration)
 
 	if !orchestration.Executable() {
 		app.Logger.
 			Debug().
 			Str("Status", orchestration.Status.String()).
 			Msgf("Orchestration %s cannot be executed: %s", orchestration.ID, orchestration.Error)
+		app.Plane.NotifyEvent(orchestration.ProjectID, newOrchestrationEvent(orchestration))
 		w.WriteHeader(http.StatusUnprocessableEntity)
 	} else {
 		app.Logger.Debug().Msgf("About to execute orchestration %s", orchestration.ID)
-		go app.Plane.ExecuteOrchestration(&orchestration)
+		go app.Plane.ExecuteOrchestration(orchestration)
 		w.WriteHeader(http.StatusAccepted)
 	}
 
@@ -222,18 +658,241 @@ func (app *App) OrchestrationsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// decodeOrchestrationRequest decodes a new orchestration for the project, only the action, its data and
+// either a plan or a workflow are taken from the request.
+func decodeOrchestrationRequest(r *http.Request, project *Project) (*Orchestration, error) {
+	var request Orchestration
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+
+	return &Orchestration{
+		ID:        uuid.New().String(),
+		ProjectID: project.ID,
+		Action:    request.Action,
+		Params:    request.Params,
+		Workflow:  request.Workflow,
+		Plan:      request.Plan,
+		Status:    Pending,
+		Timestamp: time.Now().UTC(),
+	}, nil
+}
+
+// PlanOrchestration returns the plan for an orchestration without executing it.
+func (app *App) PlanOrchestration(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	orchestration, err := decodeOrchestrationRequest(r, project)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, errs.Code(JSONMarshalingFail), err))
+		return
+	}
+
+	app.Plane.PlanOrchestration(orchestration)
+
+	status := http.StatusOK
+	if !orchestration.Executable() {
+		status = http.StatusUnprocessableEntity
+	}
+	app.writeJSON(w, status, orchestration)
+}
+
+func (app *App) ListOrchestrations(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	filter, err := parseOrchestrationFilter(r)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+
+	page, err := app.Plane.ListOrchestrations(project.ID, filter)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, page)
+}
+
+func (app *App) InspectOrchestration(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	inspection, err := app.Plane.InspectOrchestration(project.ID, mux.Vars(r)["id"])
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, inspection)
+}
+
+func (app *App) ListOrchestrationDeliveries(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	attempts, err := app.Plane.ListDeliveryAttempts(project.ID, mux.Vars(r)["id"])
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, attempts)
+}
+
+// RedeliverOrchestration queues the orchestration's result for its webhooks again, the request's
+// optional webhookId only redelivers it to that webhook.
+func (app *App) RedeliverOrchestration(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	var request struct {
+		WebhookID string `json:"webhookId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+
+	deliveries, err := app.Plane.RedeliverOrchestration(project.ID, mux.Vars(r)["id"], request.WebhookID)
+	if errors.Is(err, ErrOrchestrationNotFinalized) {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	app.writeJSON(w, http.StatusAccepted, deliveries)
+}
+
+func (app *App) OrchestrationLogs(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	offset, err := parseLogOffset(r)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+
+	log, _, err := app.Plane.OrchestrationLog(project.ID, mux.Vars(r)["id"])
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	entries := log.ReadFrom(offset)
+	if entries == nil {
+		entries = []LogEntry{}
+	}
+
+	app.writeJSON(w, http.StatusOK, map[string]any{
+		"entries":    entries,
+		"nextOffset": max(offset, log.GetCurrentOffset()),
+	})
+}
+
+// StreamOrchestrationLogs tails an orchestration's Log as Server-Sent Events until the orchestration is finalized.
+// Every entry is sent as an event named after its type, with the entry's offset as the event ID so clients can resume.
+func (app *App) StreamOrchestrationLogs(w http.ResponseWriter, r *http.Request) {
+	project := r.Context().Value("project").(*Project)
+
+	offset, err := parseLogOffset(r)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.InvalidRequest, err))
+		return
+	}
+
+	orchestrationID := mux.Vars(r)["id"]
+	log, active, err := app.Plane.OrchestrationLog(project.ID, orchestrationID)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		app.Logger.Debug().Err(err).Msg("Cannot clear write deadline for log stream")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	poll := time.NewTicker(LogStreamPollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(LogStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		for _, entry := range log.ReadFrom(offset) {
+			if entry.Type != "task_output" && entry.Type != "task_failure" {
+				continue
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				app.Logger.Error().Err(err).Msg("Failed to marshal log entry for stream")
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.Offset, entry.Type, data); err != nil {
+				return
+			}
+		}
+		offset = max(offset, log.GetCurrentOffset())
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		current := app.Plane.LogManager.GetLog(orchestrationID)
+		switch {
+		case !active && current != nil:
+			// The orchestration started executing after the stream was opened
+			log, active = current, true
+			continue
+		case active && current != log:
+			// Drain anything appended before the orchestration was finalized
+			active = false
+			continue
+		case !active:
+			if orchestration, err := app.Plane.GetProjectOrchestration(project.ID, orchestrationID); err == nil &&
+				orchestration.Status != Pending && orchestration.Status != Processing {
+				_, _ = fmt.Fprintf(w, "event: end\ndata: {\"status\":%q}\n\n", orchestration.Status.String())
+				_ = rc.Flush()
+				return
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-poll.C:
+		}
+	}
+}
+
 func (app *App) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	serviceID := r.URL.Query().Get("serviceId")
 
 	// Perform API key authentication
 	apiKey := r.URL.Query().Get("apiKey")
-	project, err := app.Plane.GetProjectByApiKey(apiKey)
+	project, key, err := app.Plane.AuthenticateAPIKey(apiKey)
 	if err != nil {
 		app.Logger.Error().Err(err).Msg("Invalid API key for WebSocket connection")
 		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, err))
 		return
 	}
 
+	if !key.Allows(ScopeRegisterService) {
+		app.Logger.Error().Str("serviceID", serviceID).Msg("API key cannot be used for WebSocket connections")
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, fmt.Sprintf("API key is missing the %s scope", ScopeRegisterService)))
+		return
+	}
+
 	if !app.Plane.ServiceBelongsToProject(serviceID, project.ID) {
 		app.Logger.Error().Str("serviceID", serviceID).Msg("Service not found for the given project")
 		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, err))
@@ -246,3 +905,118 @@ func (app *App) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+func (app *App) writeJSON(w http.ResponseWriter, status int, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unanticipated, errs.Code(JSONMarshalingFail), err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err = w.Write(data); err != nil {
+		app.Logger.Error().Err(err).Msg("Failed to write response")
+	}
+}
+
+// storeErrorKind maps missing items to a not found error, anything else is unanticipated.
+func storeErrorKind(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return errs.E(errs.NotExist, err)
+	}
+	return errs.E(errs.Unanticipated, err)
+}
+
+func parseOrchestrationFilter(r *http.Request) (OrchestrationFilter, error) {
+	query := r.URL.Query()
+	var filter OrchestrationFilter
+
+	if statuses := query.Get("status"); statuses != "" {
+		for _, val := range strings.Split(statuses, ",") {
+			status, err := ParseStatus(val)
+			if err != nil {
+				return filter, err
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	for param, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		val := query.Get(param)
+		if val == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s, expected an RFC3339 timestamp: %s", param, val)
+		}
+		*target = parsed
+	}
+
+	if val := query.Get("limit"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil || limit <= 0 {
+			return filter, fmt.Errorf("invalid limit: %s", val)
+		}
+		filter.Limit = limit
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		if _, _, err := decodeCursor(cursor); err != nil {
+			return filter, err
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
+
+// decodeWebhookRequest reads a webhook's URL and subscribed events, the URL can only be left out when updating.
+func decodeWebhookRequest(r *http.Request, urlRequired bool) (string, []EventType, error) {
+	var request struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return "", nil, err
+	}
+
+	if request.URL != "" || urlRequired {
+		if err := validateWebhook(request.URL); err != nil {
+			return "", nil, err
+		}
+	}
+
+	events := make([]EventType, 0, len(request.Events))
+	for _, val := range request.Events {
+		event, err := ParseEventType(val)
+		if err != nil {
+			return "", nil, err
+		}
+		events = append(events, event)
+	}
+
+	return request.URL, events, nil
+}
+
+// parseLogOffset reads the offset to read a Log from, either from the query or a resuming SSE client's Last-Event-ID.
+func parseLogOffset(r *http.Request) (uint64, error) {
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		lastOffset, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid Last-Event-ID: %s", lastEventID)
+		}
+		return lastOffset + 1, nil
+	}
+
+	val := r.URL.Query().Get("offset")
+	if val == "" {
+		return 0, nil
+	}
+	offset, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset: %s", val)
+	}
+	return offset, nil
+}
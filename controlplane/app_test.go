@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
+func newLogsTestApp(t *testing.T) (*App, *MemoryStore) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	_ = store.SaveProject(&Project{ID: "p1", APIKey: "key"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	plane := NewControlPlane(nil, store)
+	plane.Logger = zerolog.Nop()
+	if err := plane.MigrateProjects(); err != nil {
+		t.Fatalf("failed to migrate projects: %v", err)
+	}
+	if err := plane.LoadAPIKeys(); err != nil {
+		t.Fatalf("failed to load API keys: %v", err)
+	}
+	plane.LogManager = NewLogManager(ctx, t.TempDir(), LogsRetentionPeriod, plane)
+	plane.LogManager.Logger = zerolog.Nop()
+
+	app := &App{Plane: plane, Router: mux.NewRouter(), Logger: zerolog.Nop()}
+	app.configureRoutes()
+	return app, store
+}
+
+func serveLogs(app *App, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer key")
+	rec := httptest.NewRecorder()
+	app.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOrchestrationLogsServesLiveAndRetainedLogs(t *testing.T) {
+	app, store := newLogsTestApp(t)
+
+	_ = store.SaveOrchestration(&Orchestration{ID: "live", ProjectID: "p1", Status: Processing})
+	live, err := app.Plane.LogManager.CreateLog("live", &ServiceCallingPlan{ProjectID: "p1"})
+	if err != nil {
+		t.Fatalf("failed to create log: %v", err)
+	}
+	_ = live.Append(LogEntry{Type: "task_output", ID: TaskZero, Value: json.RawMessage(`{"message":"hello"}`)})
+	_ = live.Append(LogEntry{Type: "task_output", ID: "task1", Value: json.RawMessage(`{"echo":"hello"}`)})
+
+	_ = store.SaveOrchestration(&Orchestration{ID: "retained", ProjectID: "p1", Status: Completed})
+	segments, err := OpenSegmentLog(filepath.Join(app.Plane.LogManager.dir, "retained"))
+	if err != nil {
+		t.Fatalf("failed to open segment log: %v", err)
+	}
+	_ = segments.Append(LogEntry{Offset: 0, Type: "task_output", ID: TaskZero, Value: json.RawMessage(`{"message":"bye"}`)})
+	_ = segments.Close()
+
+	tests := []struct {
+		path           string
+		wantIDs        []string
+		wantNextOffset uint64
+	}{
+		{path: "/orchestrations/live/logs", wantIDs: []string{TaskZero, "task1"}, wantNextOffset: 2},
+		{path: "/orchestrations/live/logs?offset=1", wantIDs: []string{"task1"}, wantNextOffset: 2},
+		{path: "/orchestrations/retained/logs", wantIDs: []string{TaskZero}, wantNextOffset: 1},
+	}
+
+	for _, tt := range tests {
+		rec := serveLogs(app, tt.path)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d: %s", tt.path, rec.Code, rec.Body.String())
+		}
+
+		var body struct {
+			Entries    []LogEntry `json:"entries"`
+			NextOffset uint64     `json:"nextOffset"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s: failed to decode response: %v", tt.path, err)
+		}
+
+		var ids []string
+		for _, entry := range body.Entries {
+			ids = append(ids, entry.ID)
+		}
+		if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+			t.Errorf("GET %s: expected entries %v, got %v", tt.path, tt.wantIDs, ids)
+		}
+		if body.NextOffset != tt.wantNextOffset {
+			t.Errorf("GET %s: expected next offset %d, got %d", tt.path, tt.wantNextOffset, body.NextOffset)
+		}
+	}
+}
+
+func TestOrchestrationLogsRejectsUnknownOrchestrations(t *testing.T) {
+	app, store := newLogsTestApp(t)
+	_ = store.SaveOrchestration(&Orchestration{ID: "other", ProjectID: "p2", Status: Completed})
+
+	for _, path := range []string{
+		"/orchestrations/missing/logs",
+		"/orchestrations/other/logs",
+		"/orchestrations/missing/logs/stream",
+	} {
+		if rec := serveLogs(app, path); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: expected status 400, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestStreamOrchestrationLogsFramesEntriesAsEvents(t *testing.T) {
+	app, store := newLogsTestApp(t)
+
+	_ = store.SaveOrchestration(&Orchestration{ID: "o1", ProjectID: "p1", Status: Completed})
+	segments, err := OpenSegmentLog(filepath.Join(app.Plane.LogManager.dir, "o1"))
+	if err != nil {
+		t.Fatalf("failed to open segment log: %v", err)
+	}
+	_ = segments.Append(LogEntry{Offset: 0, Type: "task_output", ID: TaskZero, Value: json.RawMessage(`{"message":"hello"}`)})
+	_ = segments.Append(LogEntry{Offset: 1, Type: "task_status", ID: "task1"})
+	_ = segments.Append(LogEntry{Offset: 2, Type: "task_output", ID: "task1", Value: json.RawMessage(`{"echo":"hello"}`)})
+	_ = segments.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/orchestrations/o1/logs/stream", nil)
+	req.Header.Set("Authorization", "Bearer key")
+	req.Header.Set("Last-Event-ID", "0")
+	rec := httptest.NewRecorder()
+	app.Router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("expected an event stream, got content type %q", contentType)
+	}
+
+	events := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n\n"), "\n\n")
+	if len(events) != 2 {
+		t.Fatalf("expected the task1 output and the end event, got %q", rec.Body.String())
+	}
+
+	lines := strings.Split(events[0], "\n")
+	if len(lines) != 3 || lines[0] != "id: 2" || lines[1] != "event: task_output" || !strings.HasPrefix(lines[2], "data: ") {
+		t.Fatalf("unexpected event framing: %q", events[0])
+	}
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &entry); err != nil {
+		t.Fatalf("failed to decode event data: %v", err)
+	}
+	if entry.ID != "task1" || string(entry.Value) != `{"echo":"hello"}` {
+		t.Errorf("unexpected streamed entry: %+v", entry)
+	}
+
+	if events[1] != "event: end\ndata: {\"status\":\"completed\"}" {
+		t.Errorf("unexpected end event: %q", events[1])
+	}
+}
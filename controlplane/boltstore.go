@@ -0,0 +1,828 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	settingsBucket              = []byte("settings")
+	projectsBucket              = []byte("projects")
+	apiKeysBucket               = []byte("api_keys")
+	servicesBucket              = []byte("services")
+	serviceVersionsBucket       = []byte("service_versions")
+	workflowsBucket             = []byte("workflows")
+	orchestrationsBucket        = []byte("orchestrations")
+	projectOrchestrationsBucket = []byte("project_orchestrations")
+	orchestrationStatesBucket   = []byte("orchestration_states")
+	workerStatesBucket          = []byte("worker_states")
+	webhookDeliveriesBucket     = []byte("webhook_deliveries")
+	// deliveryScheduleBucket indexes pending deliveries by when they are next due, keyed by scheduleKey
+	deliveryScheduleBucket = []byte("webhook_delivery_schedule")
+	deliveryAttemptsBucket = []byte("delivery_attempts")
+)
+
+var adminCredentialKey = []byte("admin_credential")
+
+// BoltStore persists the control plane's state in an embedded BoltDB file.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// projectRecord, apiKeyRecord, serviceRecord, workflowRecord and orchestrationRecord keep the fields hidden
+// from the API's JSON when persisting.
+type projectRecord struct {
+	Project
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+}
+
+type apiKeyRecord struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+type serviceRecord struct {
+	ServiceInfo
+	ProjectID string `json:"projectId"`
+}
+
+type workflowRecord struct {
+	Workflow
+	ProjectID string `json:"projectId"`
+}
+
+type orchestrationRecord struct {
+	Orchestration
+	ProjectID string          `json:"projectId"`
+	TaskZero  json.RawMessage `json:"taskZero,omitempty"`
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: StoreOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage at %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		// Deliveries queued before the schedule existed are indexed once
+		indexDeliveries := tx.Bucket(deliveryScheduleBucket) == nil
+
+		for _, name := range [][]byte{
+			settingsBucket,
+			projectsBucket,
+			apiKeysBucket,
+			servicesBucket,
+			serviceVersionsBucket,
+			workflowsBucket,
+			orchestrationsBucket,
+			projectOrchestrationsBucket,
+			orchestrationStatesBucket,
+			workerStatesBucket,
+			webhookDeliveriesBucket,
+			deliveryScheduleBucket,
+			deliveryAttemptsBucket,
+		} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		if !indexDeliveries {
+			return nil
+		}
+		return tx.Bucket(webhookDeliveriesBucket).ForEach(func(_, data []byte) error {
+			var delivery WebhookDelivery
+			if err := json.Unmarshal(data, &delivery); err != nil {
+				return fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+			}
+			return scheduleDelivery(tx, &delivery)
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialise storage buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) SaveProject(project *Project) error {
+	data, err := json.Marshal(&projectRecord{Project: *project, WebhookSecret: project.WebhookSecret})
+	if err != nil {
+		return fmt.Errorf("failed to marshal project %s: %w", project.ID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(projectsBucket).Put([]byte(project.ID), data)
+	})
+}
+
+func (s *BoltStore) GetProject(id string) (*Project, error) {
+	var project *Project
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(projectsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("project %s: %w", id, ErrNotFound)
+		}
+		var err error
+		project, err = unmarshalProject(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *BoltStore) ListProjects() ([]*Project, error) {
+	var out []*Project
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(projectsBucket).ForEach(func(_, data []byte) error {
+			project, err := unmarshalProject(data)
+			if err != nil {
+				return err
+			}
+			out = append(out, project)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) DeleteProject(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		projects := tx.Bucket(projectsBucket)
+		if projects.Get([]byte(id)) == nil {
+			return fmt.Errorf("project %s: %w", id, ErrNotFound)
+		}
+
+		if projectIndex := tx.Bucket(projectOrchestrationsBucket).Bucket([]byte(id)); projectIndex != nil {
+			err := projectIndex.ForEach(func(orchestrationID, _ []byte) error {
+				if err := tx.Bucket(orchestrationsBucket).Delete(orchestrationID); err != nil {
+					return err
+				}
+				if err := tx.Bucket(orchestrationStatesBucket).Delete(orchestrationID); err != nil {
+					return err
+				}
+				if err := deleteBucketIfExists(tx.Bucket(deliveryAttemptsBucket), orchestrationID); err != nil {
+					return err
+				}
+				return deleteBucketIfExists(tx.Bucket(workerStatesBucket), orchestrationID)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := deleteBucketIfExists(tx.Bucket(projectOrchestrationsBucket), []byte(id)); err != nil {
+			return err
+		}
+		if err := deleteBucketIfExists(tx.Bucket(servicesBucket), []byte(id)); err != nil {
+			return err
+		}
+		if err := deleteBucketIfExists(tx.Bucket(serviceVersionsBucket), []byte(id)); err != nil {
+			return err
+		}
+		if err := deleteBucketIfExists(tx.Bucket(workflowsBucket), []byte(id)); err != nil {
+			return err
+		}
+		if err := deleteBucketIfExists(tx.Bucket(apiKeysBucket), []byte(id)); err != nil {
+			return err
+		}
+
+		deliveries := tx.Bucket(webhookDeliveriesBucket)
+		var deliveryIDs [][]byte
+		err := deliveries.ForEach(func(deliveryID, data []byte) error {
+			var delivery WebhookDelivery
+			if err := json.Unmarshal(data, &delivery); err != nil {
+				return fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+			}
+			if delivery.ProjectID == id {
+				deliveryIDs = append(deliveryIDs, deliveryID)
+				return unscheduleDelivery(tx, &delivery)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, deliveryID := range deliveryIDs {
+			if err := deliveries.Delete(deliveryID); err != nil {
+				return err
+			}
+		}
+
+		return projects.Delete([]byte(id))
+	})
+}
+
+func (s *BoltStore) SaveAPIKey(key *APIKey) error {
+	data, err := json.Marshal(&apiKeyRecord{APIKey: *key, Hash: key.Hash})
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key %s: %w", key.ID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		projectKeys, err := tx.Bucket(apiKeysBucket).CreateBucketIfNotExists([]byte(key.ProjectID))
+		if err != nil {
+			return err
+		}
+		return projectKeys.Put([]byte(key.ID), data)
+	})
+}
+
+func (s *BoltStore) ListAPIKeys(projectID string) ([]*APIKey, error) {
+	var out []*APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		projectKeys := tx.Bucket(apiKeysBucket).Bucket([]byte(projectID))
+		if projectKeys == nil {
+			return nil
+		}
+		return projectKeys.ForEach(func(_, data []byte) error {
+			var record apiKeyRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				return fmt.Errorf("failed to unmarshal API key: %w", err)
+			}
+			record.APIKey.Hash = record.Hash
+			out = append(out, &record.APIKey)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) SaveAdminCredential(credential *AdminCredential) error {
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return fmt.Errorf("failed to marshal admin credential: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(settingsBucket).Put(adminCredentialKey, data)
+	})
+}
+
+func (s *BoltStore) GetAdminCredential() (*AdminCredential, error) {
+	var credential AdminCredential
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(settingsBucket).Get(adminCredentialKey)
+		if data == nil {
+			return fmt.Errorf("admin credential: %w", ErrNotFound)
+		}
+		return json.Unmarshal(data, &credential)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (s *BoltStore) SaveService(service *ServiceInfo) error {
+	data, err := json.Marshal(&serviceRecord{ServiceInfo: *service, ProjectID: service.ProjectID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal service %s: %w", service.ID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		projectServices, err := tx.Bucket(servicesBucket).CreateBucketIfNotExists([]byte(service.ProjectID))
+		if err != nil {
+			return err
+		}
+		if err := projectServices.Put([]byte(service.ID), data); err != nil {
+			return err
+		}
+
+		projectVersions, err := tx.Bucket(serviceVersionsBucket).CreateBucketIfNotExists([]byte(service.ProjectID))
+		if err != nil {
+			return err
+		}
+		versions, err := projectVersions.CreateBucketIfNotExists([]byte(service.ID))
+		if err != nil {
+			return err
+		}
+		return versions.Put(versionKey(service.Version), data)
+	})
+}
+
+func (s *BoltStore) GetService(projectID, serviceID string) (*ServiceInfo, error) {
+	var service *ServiceInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		projectServices := tx.Bucket(servicesBucket).Bucket([]byte(projectID))
+		if projectServices == nil {
+			return fmt.Errorf("service %s for project %s: %w", serviceID, projectID, ErrNotFound)
+		}
+		data := projectServices.Get([]byte(serviceID))
+		if data == nil {
+			return fmt.Errorf("service %s for project %s: %w", serviceID, projectID, ErrNotFound)
+		}
+		var err error
+		service, err = unmarshalService(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+func (s *BoltStore) ListServices(projectID string) ([]*ServiceInfo, error) {
+	var out []*ServiceInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		projectServices := tx.Bucket(servicesBucket).Bucket([]byte(projectID))
+		if projectServices == nil {
+			return nil
+		}
+		return projectServices.ForEach(func(_, data []byte) error {
+			service, err := unmarshalService(data)
+			if err != nil {
+				return err
+			}
+			out = append(out, service)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) GetServiceVersion(projectID, serviceID string, version int64) (*ServiceInfo, error) {
+	var service *ServiceInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var data []byte
+		if versions := serviceVersions(tx, projectID, serviceID); versions != nil {
+			data = versions.Get(versionKey(version))
+		}
+		if data == nil {
+			return fmt.Errorf("service %s version %d for project %s: %w", serviceID, version, projectID, ErrNotFound)
+		}
+		var err error
+		service, err = unmarshalService(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+func (s *BoltStore) ListServiceVersions(projectID, serviceID string) ([]*ServiceInfo, error) {
+	var out []*ServiceInfo
+	err := s.db.View(func(tx *bolt.Tx) error {
+		versions := serviceVersions(tx, projectID, serviceID)
+		if versions == nil {
+			return nil
+		}
+		return versions.ForEach(func(_, data []byte) error {
+			service, err := unmarshalService(data)
+			if err != nil {
+				return err
+			}
+			out = append(out, service)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) SaveWorkflow(workflow *Workflow) error {
+	data, err := json.Marshal(&workflowRecord{Workflow: *workflow, ProjectID: workflow.ProjectID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal workflow %s: %w", workflow.Name, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		projectWorkflows, err := tx.Bucket(workflowsBucket).CreateBucketIfNotExists([]byte(workflow.ProjectID))
+		if err != nil {
+			return err
+		}
+		return projectWorkflows.Put([]byte(workflow.Name), data)
+	})
+}
+
+func (s *BoltStore) GetWorkflow(projectID, name string) (*Workflow, error) {
+	var workflow *Workflow
+	err := s.db.View(func(tx *bolt.Tx) error {
+		projectWorkflows := tx.Bucket(workflowsBucket).Bucket([]byte(projectID))
+		if projectWorkflows == nil {
+			return fmt.Errorf("workflow %s for project %s: %w", name, projectID, ErrNotFound)
+		}
+		data := projectWorkflows.Get([]byte(name))
+		if data == nil {
+			return fmt.Errorf("workflow %s for project %s: %w", name, projectID, ErrNotFound)
+		}
+		var err error
+		workflow, err = unmarshalWorkflow(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+func (s *BoltStore) ListWorkflows(projectID string) ([]*Workflow, error) {
+	var out []*Workflow
+	err := s.db.View(func(tx *bolt.Tx) error {
+		projectWorkflows := tx.Bucket(workflowsBucket).Bucket([]byte(projectID))
+		if projectWorkflows == nil {
+			return nil
+		}
+		return projectWorkflows.ForEach(func(_, data []byte) error {
+			workflow, err := unmarshalWorkflow(data)
+			if err != nil {
+				return err
+			}
+			out = append(out, workflow)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) DeleteWorkflow(projectID, name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		projectWorkflows := tx.Bucket(workflowsBucket).Bucket([]byte(projectID))
+		if projectWorkflows == nil || projectWorkflows.Get([]byte(name)) == nil {
+			return fmt.Errorf("workflow %s for project %s: %w", name, projectID, ErrNotFound)
+		}
+		return projectWorkflows.Delete([]byte(name))
+	})
+}
+
+func (s *BoltStore) SaveOrchestration(orchestration *Orchestration) error {
+	data, err := json.Marshal(&orchestrationRecord{
+		Orchestration: *orchestration,
+		ProjectID:     orchestration.ProjectID,
+		TaskZero:      orchestration.taskZero,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal orchestration %s: %w", orchestration.ID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(orchestrationsBucket).Put([]byte(orchestration.ID), data); err != nil {
+			return err
+		}
+		projectIndex, err := tx.Bucket(projectOrchestrationsBucket).CreateBucketIfNotExists([]byte(orchestration.ProjectID))
+		if err != nil {
+			return err
+		}
+		return projectIndex.Put([]byte(orchestration.ID), []byte{})
+	})
+}
+
+func (s *BoltStore) GetOrchestration(id string) (*Orchestration, error) {
+	var orchestration *Orchestration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(orchestrationsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("orchestration %s: %w", id, ErrNotFound)
+		}
+		var err error
+		orchestration, err = unmarshalOrchestration(data)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orchestration, nil
+}
+
+func (s *BoltStore) ListOrchestrations(projectID string) ([]*Orchestration, error) {
+	var out []*Orchestration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		projectIndex := tx.Bucket(projectOrchestrationsBucket).Bucket([]byte(projectID))
+		if projectIndex == nil {
+			return nil
+		}
+		orchestrations := tx.Bucket(orchestrationsBucket)
+		return projectIndex.ForEach(func(id, _ []byte) error {
+			data := orchestrations.Get(id)
+			if data == nil {
+				return nil
+			}
+			orchestration, err := unmarshalOrchestration(data)
+			if err != nil {
+				return err
+			}
+			out = append(out, orchestration)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) SaveOrchestrationState(state *OrchestrationState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal orchestration state %s: %w", state.ID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(orchestrationStatesBucket).Put([]byte(state.ID), data)
+	})
+}
+
+func (s *BoltStore) GetOrchestrationState(id string) (*OrchestrationState, error) {
+	var state OrchestrationState
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(orchestrationStatesBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("orchestration state %s: %w", id, ErrNotFound)
+		}
+		return json.Unmarshal(data, &state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *BoltStore) SaveWorkerState(orchestrationID, workerID string, state *LogState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal worker %s state: %w", workerID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		workers, err := tx.Bucket(workerStatesBucket).CreateBucketIfNotExists([]byte(orchestrationID))
+		if err != nil {
+			return err
+		}
+		return workers.Put([]byte(workerID), data)
+	})
+}
+
+func (s *BoltStore) GetWorkerState(orchestrationID, workerID string) (*LogState, error) {
+	var state LogState
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var data []byte
+		if workers := tx.Bucket(workerStatesBucket).Bucket([]byte(orchestrationID)); workers != nil {
+			data = workers.Get([]byte(workerID))
+		}
+		if data == nil {
+			return fmt.Errorf("worker %s state for orchestration %s: %w", workerID, orchestrationID, ErrNotFound)
+		}
+		return json.Unmarshal(data, &state)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *BoltStore) SaveWebhookDelivery(delivery *WebhookDelivery) error {
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook delivery %s: %w", delivery.ID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := unscheduleStoredDelivery(tx, []byte(delivery.ID)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(webhookDeliveriesBucket).Put([]byte(delivery.ID), data); err != nil {
+			return err
+		}
+		return scheduleDelivery(tx, delivery)
+	})
+}
+
+func (s *BoltStore) GetWebhookDelivery(id string) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(webhookDeliveriesBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("webhook delivery %s: %w", id, ErrNotFound)
+		}
+		return json.Unmarshal(data, &delivery)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s *BoltStore) ListWebhookDeliveries(projectID string) ([]*WebhookDelivery, error) {
+	var out []*WebhookDelivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(webhookDeliveriesBucket).ForEach(func(_, data []byte) error {
+			var delivery WebhookDelivery
+			if err := json.Unmarshal(data, &delivery); err != nil {
+				return fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+			}
+			if projectID == "" || delivery.ProjectID == projectID {
+				out = append(out, &delivery)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) ListDueWebhookDeliveries(now time.Time) ([]*WebhookDelivery, time.Time, error) {
+	var due []*WebhookDelivery
+	var next time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		deliveries := tx.Bucket(webhookDeliveriesBucket)
+		cursor := tx.Bucket(deliveryScheduleBucket).Cursor()
+		for key, id := cursor.First(); key != nil; key, id = cursor.Next() {
+			dueAt := time.Unix(0, int64(binary.BigEndian.Uint64(key[:8])))
+			if dueAt.After(now) {
+				next = dueAt
+				return nil
+			}
+
+			data := deliveries.Get(id)
+			if data == nil {
+				continue
+			}
+			var delivery WebhookDelivery
+			if err := json.Unmarshal(data, &delivery); err != nil {
+				return fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+			}
+			due = append(due, &delivery)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return due, next, nil
+}
+
+func (s *BoltStore) DeleteWebhookDelivery(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		deliveries := tx.Bucket(webhookDeliveriesBucket)
+		if deliveries.Get([]byte(id)) == nil {
+			return fmt.Errorf("webhook delivery %s: %w", id, ErrNotFound)
+		}
+		if err := unscheduleStoredDelivery(tx, []byte(id)); err != nil {
+			return err
+		}
+		return deliveries.Delete([]byte(id))
+	})
+}
+
+// scheduleDelivery indexes a pending delivery by when it is next due, dead letters are not indexed.
+func scheduleDelivery(tx *bolt.Tx, delivery *WebhookDelivery) error {
+	if delivery.Dead() {
+		return nil
+	}
+	return tx.Bucket(deliveryScheduleBucket).Put(scheduleKey(delivery), []byte(delivery.ID))
+}
+
+func unscheduleDelivery(tx *bolt.Tx, delivery *WebhookDelivery) error {
+	return tx.Bucket(deliveryScheduleBucket).Delete(scheduleKey(delivery))
+}
+
+// unscheduleStoredDelivery removes the index entry of the delivery as it is currently stored, if any.
+func unscheduleStoredDelivery(tx *bolt.Tx, id []byte) error {
+	data := tx.Bucket(webhookDeliveriesBucket).Get(id)
+	if data == nil {
+		return nil
+	}
+	var stored WebhookDelivery
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to unmarshal webhook delivery: %w", err)
+	}
+	return unscheduleDelivery(tx, &stored)
+}
+
+// scheduleKey orders deliveries by when they are next due, the delivery's ID keeps keys unique.
+func scheduleKey(delivery *WebhookDelivery) []byte {
+	key := binary.BigEndian.AppendUint64(nil, uint64(delivery.NextAttemptAt.UnixNano()))
+	return append(key, delivery.ID...)
+}
+
+func (s *BoltStore) SaveDeliveryAttempt(attempt *DeliveryAttempt) error {
+	data, err := json.Marshal(attempt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal delivery attempt %s: %w", attempt.ID, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		attempts, err := tx.Bucket(deliveryAttemptsBucket).CreateBucketIfNotExists([]byte(attempt.OrchestrationID))
+		if err != nil {
+			return err
+		}
+		return attempts.Put([]byte(attempt.ID), data)
+	})
+}
+
+func (s *BoltStore) ListDeliveryAttempts(orchestrationID string) ([]*DeliveryAttempt, error) {
+	var out []*DeliveryAttempt
+	err := s.db.View(func(tx *bolt.Tx) error {
+		attempts := tx.Bucket(deliveryAttemptsBucket).Bucket([]byte(orchestrationID))
+		if attempts == nil {
+			return nil
+		}
+		return attempts.ForEach(func(_, data []byte) error {
+			var attempt DeliveryAttempt
+			if err := json.Unmarshal(data, &attempt); err != nil {
+				return fmt.Errorf("failed to unmarshal delivery attempt: %w", err)
+			}
+			out = append(out, &attempt)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func deleteBucketIfExists(parent *bolt.Bucket, name []byte) error {
+	if err := parent.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+		return err
+	}
+	return nil
+}
+
+func serviceVersions(tx *bolt.Tx, projectID, serviceID string) *bolt.Bucket {
+	projectVersions := tx.Bucket(serviceVersionsBucket).Bucket([]byte(projectID))
+	if projectVersions == nil {
+		return nil
+	}
+	return projectVersions.Bucket([]byte(serviceID))
+}
+
+// versionKey encodes a service version so that versions sort in order.
+func versionKey(version int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(version))
+}
+
+func unmarshalProject(data []byte) (*Project, error) {
+	var record projectRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal project: %w", err)
+	}
+	record.Project.WebhookSecret = record.WebhookSecret
+	return &record.Project, nil
+}
+
+func unmarshalService(data []byte) (*ServiceInfo, error) {
+	var record serviceRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal service: %w", err)
+	}
+	record.ServiceInfo.ProjectID = record.ProjectID
+	return &record.ServiceInfo, nil
+}
+
+func unmarshalWorkflow(data []byte) (*Workflow, error) {
+	var record workflowRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workflow: %w", err)
+	}
+	record.Workflow.ProjectID = record.ProjectID
+	return &record.Workflow, nil
+}
+
+func unmarshalOrchestration(data []byte) (*Orchestration, error) {
+	var record orchestrationRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal orchestration: %w", err)
+	}
+	record.Orchestration.ProjectID = record.ProjectID
+	record.Orchestration.taskZero = record.TaskZero
+	if record.Orchestration.Plan != nil {
+		record.Orchestration.Plan.ProjectID = record.ProjectID
+	}
+	return &record.Orchestration, nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBoltStoreSurvivesReopening(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orra.db")
+
+	store, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	project := &Project{ID: "p1", APIKey: "key", Webhook: "http://localhost/webhook"}
+	service := &ServiceInfo{
+		Type:      Service,
+		ID:        "s1",
+		Name:      "echo",
+		ProjectID: project.ID,
+		Version:   2,
+		Schema: ServiceSchema{
+			Input: Spec{Type: "object", Properties: Properties{"message": {Type: "string"}}},
+		},
+	}
+	orchestration := &Orchestration{
+		ID:        "o1",
+		ProjectID: project.ID,
+		Action:    Action{Type: "echo", Content: "Echo this"},
+		Plan:      &ServiceCallingPlan{Tasks: []*SubTask{{ID: "task1", Service: "s1"}}},
+		Status:    Processing,
+		taskZero:  json.RawMessage(`{"message":"hi"}`),
+	}
+
+	if err := store.SaveProject(project); err != nil {
+		t.Fatalf("failed to save project: %v", err)
+	}
+	if err := store.SaveService(&ServiceInfo{Type: Service, ID: "s1", Name: "echo", ProjectID: project.ID, Version: 1}); err != nil {
+		t.Fatalf("failed to save service: %v", err)
+	}
+	if err := store.SaveService(service); err != nil {
+		t.Fatalf("failed to save service: %v", err)
+	}
+	if err := store.SaveOrchestration(orchestration); err != nil {
+		t.Fatalf("failed to save orchestration: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	store, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	gotProject, err := store.GetProject(project.ID)
+	if err != nil || gotProject.APIKey != project.APIKey {
+		t.Fatalf("got project %+v, err %v", gotProject, err)
+	}
+
+	services, err := store.ListServices(project.ID)
+	if err != nil || len(services) != 1 {
+		t.Fatalf("got services %+v, err %v", services, err)
+	}
+	if services[0].ProjectID != project.ID || services[0].Version != 2 || !services[0].Schema.InputIncludes("message") {
+		t.Errorf("service not restored correctly: %+v", services[0])
+	}
+
+	versions, err := store.ListServiceVersions(project.ID, "s1")
+	if err != nil || len(versions) != 2 || versions[0].Version != 1 || versions[1].ProjectID != project.ID {
+		t.Errorf("service versions not restored correctly: %+v, err %v", versions, err)
+	}
+	if version, err := store.GetServiceVersion(project.ID, "s1", 1); err != nil || version.Schema.InputIncludes("message") {
+		t.Errorf("got service version %+v, err %v", version, err)
+	}
+
+	orchestrations, err := store.ListOrchestrations(project.ID)
+	if err != nil || len(orchestrations) != 1 {
+		t.Fatalf("got orchestrations %+v, err %v", orchestrations, err)
+	}
+	got := orchestrations[0]
+	if got.ProjectID != project.ID || got.Status != Processing || string(got.taskZero) != `{"message":"hi"}` {
+		t.Errorf("orchestration not restored correctly: %+v", got)
+	}
+	if got.Plan == nil || got.Plan.ProjectID != project.ID || got.Plan.Tasks[0].Service != "s1" {
+		t.Errorf("orchestration plan not restored correctly: %+v", got.Plan)
+	}
+
+	if _, err := store.GetOrchestration("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestBoltStoreDeleteProjectCascades(t *testing.T) {
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "orra.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	for _, projectID := range []string{"p1", "p2"} {
+		_ = store.SaveProject(&Project{ID: projectID, APIKey: projectID + "-key"})
+		_ = store.SaveService(&ServiceInfo{ID: projectID + "-s1", ProjectID: projectID})
+		_ = store.SaveWorkflow(&Workflow{Name: "w1", ProjectID: projectID, Plan: &ServiceCallingPlan{}})
+		_ = store.SaveOrchestration(&Orchestration{ID: projectID + "-o1", ProjectID: projectID, Status: Processing})
+		_ = store.SaveOrchestrationState(&OrchestrationState{ID: projectID + "-o1", ProjectID: projectID, Status: Processing})
+		_ = store.SaveWorkerState(projectID+"-o1", "task1", &LogState{LastOffset: 1})
+		_ = store.SaveWebhookDelivery(&WebhookDelivery{ID: projectID + "-d1", ProjectID: projectID, OrchestrationID: projectID + "-o1"})
+		_ = store.SaveDeliveryAttempt(&DeliveryAttempt{ID: projectID + "-a1", DeliveryID: projectID + "-d1", OrchestrationID: projectID + "-o1"})
+	}
+
+	if err := store.DeleteProject("p1"); err != nil {
+		t.Fatalf("failed to delete project: %v", err)
+	}
+
+	if _, err := store.GetProject("p1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted project to be missing, got %v", err)
+	}
+	if services, _ := store.ListServices("p1"); len(services) != 0 {
+		t.Errorf("expected deleted project's services to be removed, got %+v", services)
+	}
+	if versions, _ := store.ListServiceVersions("p1", "p1-s1"); len(versions) != 0 {
+		t.Errorf("expected deleted project's service versions to be removed, got %+v", versions)
+	}
+	if versions, _ := store.ListServiceVersions("p2", "p2-s1"); len(versions) != 1 {
+		t.Errorf("expected other project's service versions to be kept, got %+v", versions)
+	}
+	if _, err := store.GetWorkflow("p1", "w1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted project's workflow to be missing, got %v", err)
+	}
+	if workflow, err := store.GetWorkflow("p2", "w1"); err != nil || workflow.ProjectID != "p2" {
+		t.Errorf("expected other project's workflow to be kept, got %+v, err %v", workflow, err)
+	}
+	if _, err := store.GetOrchestration("p1-o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted project's orchestration to be missing, got %v", err)
+	}
+	if _, err := store.GetOrchestrationState("p1-o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted project's orchestration state to be missing, got %v", err)
+	}
+	if _, err := store.GetWorkerState("p1-o1", "task1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted project's worker state to be missing, got %v", err)
+	}
+	if _, err := store.GetWebhookDelivery("p1-d1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted project's webhook delivery to be missing, got %v", err)
+	}
+	if attempts, _ := store.ListDeliveryAttempts("p1-o1"); len(attempts) != 0 {
+		t.Errorf("expected deleted project's delivery attempts to be removed, got %+v", attempts)
+	}
+	if deliveries, _ := store.ListWebhookDeliveries(""); len(deliveries) != 1 || deliveries[0].ProjectID != "p2" {
+		t.Errorf("expected other project's webhook deliveries to be kept, got %+v", deliveries)
+	}
+	if due, _, _ := store.ListDueWebhookDeliveries(time.Now()); len(due) != 1 || due[0].ProjectID != "p2" {
+		t.Errorf("expected only other project's webhook deliveries to be due, got %+v", due)
+	}
+
+	if orchestrations, _ := store.ListOrchestrations("p2"); len(orchestrations) != 1 {
+		t.Errorf("expected other project's orchestrations to be kept, got %+v", orchestrations)
+	}
+	if err := store.DeleteProject("p1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting a missing project, got %v", err)
+	}
+}
+
+func TestStoresListOnlyDueWebhookDeliveries(t *testing.T) {
+	boltStore, err := NewBoltStore(filepath.Join(t.TempDir(), "orra.db"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer boltStore.Close()
+
+	now := time.Now().UTC()
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "bolt": boltStore} {
+		t.Run(name, func(t *testing.T) {
+			_ = store.SaveWebhookDelivery(&WebhookDelivery{ID: "later", NextAttemptAt: now.Add(time.Minute)})
+			_ = store.SaveWebhookDelivery(&WebhookDelivery{ID: "due", NextAttemptAt: now.Add(-time.Minute)})
+			_ = store.SaveWebhookDelivery(&WebhookDelivery{ID: "dead", NextAttemptAt: now.Add(-time.Hour), DeadAt: &now})
+			_ = store.SaveWebhookDelivery(&WebhookDelivery{ID: "retried", NextAttemptAt: now.Add(-2 * time.Minute)})
+			// Rescheduling a delivery moves it in the schedule
+			_ = store.SaveWebhookDelivery(&WebhookDelivery{ID: "retried", NextAttemptAt: now.Add(time.Hour)})
+
+			due, next, err := store.ListDueWebhookDeliveries(now)
+			if err != nil || len(due) != 1 || due[0].ID != "due" {
+				t.Errorf("expected only the due delivery, got %+v, err %v", due, err)
+			}
+			if !next.Equal(now.Add(time.Minute)) {
+				t.Errorf("got next delivery due at %s, want %s", next, now.Add(time.Minute))
+			}
+
+			_ = store.DeleteWebhookDelivery("later")
+			if _, next, _ := store.ListDueWebhookDeliveries(now); !next.Equal(now.Add(time.Hour)) {
+				t.Errorf("expected the deleted delivery to be unscheduled, got next %s", next)
+			}
+		})
+	}
+}
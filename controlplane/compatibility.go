@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrBreakingSchemaChange = errors.New("breaking service schema change")
+
+// SchemaChange is a difference between a service's registered schema and an update to it. Breaking changes
+// can invalidate existing plans and workflows, e.g. a removed input field or an output field's new type.
+type SchemaChange struct {
+	Field    string `json:"field"`
+	Change   string `json:"change"`
+	Breaking bool   `json:"breaking"`
+}
+
+func (c SchemaChange) String() string {
+	return c.Field + ": " + c.Change
+}
+
+// compareServiceSchemas lists the changes from the old to the new schema. Plans provide the inputs so new
+// constraints on them are breaking, later tasks consume the outputs so loosened guarantees on them are.
+func compareServiceSchemas(old, new ServiceSchema) []SchemaChange {
+	var changes schemaChanges
+	changes.compare("input", old.Input, new.Input, true)
+	changes.compare("output", old.Output, new.Output, false)
+	return changes
+}
+
+// breakingChanges filters the breaking changes.
+func breakingChanges(changes []SchemaChange) []SchemaChange {
+	var breaking []SchemaChange
+	for _, change := range changes {
+		if change.Breaking {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+func joinChanges(changes []SchemaChange) string {
+	descriptions := make([]string, len(changes))
+	for i, change := range changes {
+		descriptions[i] = change.String()
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+type schemaChanges []SchemaChange
+
+func (c *schemaChanges) add(field string, breaking bool, format string, args ...any) {
+	*c = append(*c, SchemaChange{Field: field, Change: fmt.Sprintf(format, args...), Breaking: breaking})
+}
+
+// compare records the changes between the old and new spec of a field, input tells whether plans provide
+// the field or later tasks consume it.
+func (c *schemaChanges) compare(field string, old, new Spec, input bool) {
+	if old.Type != new.Type {
+		// An untyped spec accepts anything, typing an input or untyping an output is breaking
+		tightened := old.Type == "" || (new.Type != "" && !(old.Type == "integer" && new.Type == "number"))
+		loosened := new.Type == "" || (old.Type != "" && !(old.Type == "number" && new.Type == "integer"))
+		c.add(field, (input && tightened) || (!input && loosened), "type changed from %s to %s", typeName(old.Type), typeName(new.Type))
+		return
+	}
+
+	if old.Description != new.Description {
+		c.add(field, false, "description changed")
+	}
+
+	for _, name := range sortedKeys(old.Properties) {
+		if !new.IncludesProp(name) {
+			c.add(field+"."+name, true, "removed")
+		}
+	}
+	for _, name := range sortedKeys(new.Properties) {
+		if !old.IncludesProp(name) {
+			if slices.Contains(new.Required, name) {
+				c.add(field+"."+name, input, "added as required")
+			} else {
+				c.add(field+"."+name, false, "added")
+			}
+			continue
+		}
+		c.compare(field+"."+name, old.Properties[name], new.Properties[name], input)
+	}
+
+	for _, name := range new.Required {
+		// Added and removed fields are reported above
+		if !slices.Contains(old.Required, name) && (old.IncludesProp(name) || !new.IncludesProp(name)) {
+			c.add(field+"."+name, input, "became required")
+		}
+	}
+	for _, name := range old.Required {
+		if !slices.Contains(new.Required, name) && (new.IncludesProp(name) || !old.IncludesProp(name)) {
+			c.add(field+"."+name, !input, "is no longer required")
+		}
+	}
+
+	if old.Items != nil || new.Items != nil {
+		c.compare(field+"[]", valueOrZero(old.Items), valueOrZero(new.Items), input)
+	}
+
+	switch {
+	case old.Nullable && !new.Nullable:
+		c.add(field, input, "no longer allows null")
+	case !old.Nullable && new.Nullable:
+		c.add(field, !input, "allows null")
+	}
+
+	oldEnum, newEnum := enumValues(old.Enum), enumValues(new.Enum)
+	for _, value := range oldEnum {
+		if len(newEnum) > 0 && !slices.Contains(newEnum, value) {
+			c.add(field, input, "enum value %s removed", value)
+		}
+	}
+	for _, value := range newEnum {
+		if len(oldEnum) > 0 && !slices.Contains(oldEnum, value) {
+			c.add(field, !input, "enum value %s added", value)
+		}
+	}
+	if len(oldEnum) == 0 && len(newEnum) > 0 {
+		c.add(field, input, "restricted to %s", strings.Join(newEnum, ", "))
+	}
+	if len(oldEnum) > 0 && len(newEnum) == 0 {
+		c.add(field, !input, "no longer restricted to %s", strings.Join(oldEnum, ", "))
+	}
+
+	c.compareConstraint(field, "format", old.Format, new.Format, input)
+	c.compareConstraint(field, "pattern", old.Pattern, new.Pattern, input)
+	c.compareBound(field, "minimum", old.Minimum, new.Minimum, true, input)
+	c.compareBound(field, "maximum", old.Maximum, new.Maximum, false, input)
+	c.compareBound(field, "minLength", intBound(old.MinLength), intBound(new.MinLength), true, input)
+	c.compareBound(field, "maxLength", intBound(old.MaxLength), intBound(new.MaxLength), false, input)
+}
+
+// compareConstraint records a changed format or pattern, a new constraint is treated like a tightened one.
+func (c *schemaChanges) compareConstraint(field, name, old, new string, input bool) {
+	switch {
+	case old == new:
+	case old == "":
+		c.add(field, input, "%s %s added", name, new)
+	case new == "":
+		c.add(field, !input, "%s %s removed", name, old)
+	default:
+		c.add(field, true, "%s changed from %s to %s", name, old, new)
+	}
+}
+
+// compareBound records a changed lower or upper bound, tightening it breaks inputs and loosening it outputs.
+func (c *schemaChanges) compareBound(field, name string, old, new *float64, lower bool, input bool) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		c.add(field, input, "%s %v added", name, *new)
+	case new == nil:
+		c.add(field, !input, "%s %v removed", name, *old)
+	case *old != *new:
+		tightened := (lower && *new > *old) || (!lower && *new < *old)
+		c.add(field, input == tightened, "%s changed from %v to %v", name, *old, *new)
+	}
+}
+
+func enumValues(enum []any) []string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		data, _ := json.Marshal(value)
+		values = append(values, string(data))
+	}
+	return values
+}
+
+func intBound(bound *int) *float64 {
+	if bound == nil {
+		return nil
+	}
+	value := float64(*bound)
+	return &value
+}
+
+func valueOrZero[T any](value *T) T {
+	if value == nil {
+		var zero T
+		return zero
+	}
+	return *value
+}
+
+func typeName(specType string) string {
+	if specType == "" {
+		return "any"
+	}
+	return specType
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCompareServiceSchemas(t *testing.T) {
+	old := ServiceSchema{
+		Input: Spec{
+			Type:     "object",
+			Required: []string{"customerId"},
+			Properties: Properties{
+				"customerId": {Type: "string"},
+				"region":     {Type: "string", Enum: []any{"eu", "us"}},
+				"limit":      {Type: "integer", Maximum: ptr(100.0)},
+			},
+		},
+		Output: Spec{
+			Type:     "object",
+			Required: []string{"balance"},
+			Properties: Properties{
+				"balance": {Type: "integer"},
+				"name":    {Type: "string"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		update func(schema *ServiceSchema)
+		want   []SchemaChange
+	}{
+		{
+			name:   "unchanged",
+			update: func(*ServiceSchema) {},
+		},
+		{
+			name: "additive",
+			update: func(schema *ServiceSchema) {
+				schema.Input.Properties["note"] = Spec{Type: "string"}
+				schema.Input.Properties["region"] = Spec{Type: "string", Enum: []any{"eu", "us", "apac"}}
+				schema.Input.Properties["limit"] = Spec{Type: "integer", Maximum: ptr(500.0)}
+				schema.Output.Properties["email"] = Spec{Type: "string"}
+				schema.Output.Required = []string{"balance", "name"}
+			},
+			want: []SchemaChange{
+				{Field: "input.limit", Change: "maximum changed from 100 to 500"},
+				{Field: "input.note", Change: "added"},
+				{Field: "input.region", Change: `enum value "apac" added`},
+				{Field: "output.email", Change: "added"},
+				{Field: "output.name", Change: "became required"},
+			},
+		},
+		{
+			name: "breaking",
+			update: func(schema *ServiceSchema) {
+				delete(schema.Input.Properties, "customerId")
+				schema.Input.Required = nil
+				schema.Input.Properties["accountId"] = Spec{Type: "string"}
+				schema.Input.Required = []string{"accountId"}
+				schema.Input.Properties["region"] = Spec{Type: "string", Enum: []any{"eu"}}
+				schema.Output.Properties["balance"] = Spec{Type: "number", Nullable: true}
+			},
+			want: []SchemaChange{
+				{Field: "input.customerId", Change: "removed", Breaking: true},
+				{Field: "input.accountId", Change: "added as required", Breaking: true},
+				{Field: "input.region", Change: `enum value "us" removed`, Breaking: true},
+				{Field: "output.balance", Change: "type changed from integer to number", Breaking: true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			updated := cloneSchema(old)
+			tc.update(&updated)
+
+			got := compareServiceSchemas(old, updated)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got changes %+v, want %+v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("got change %+v, want %+v", got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterOrUpdateServiceRejectsBreakingChanges(t *testing.T) {
+	plane, _ := newTestPlane(t, NewFakeLLM(), echoService())
+
+	update := echoServiceUpdate()
+	if _, err := plane.RegisterOrUpdateService(update, true); !errors.Is(err, ErrBreakingSchemaChange) {
+		t.Fatalf("expected ErrBreakingSchemaChange, got %v", err)
+	}
+	if schema, _ := plane.GetServiceSchema("p1", update.ID, 0); !schema.InputIncludes("message") {
+		t.Errorf("expected the rejected update not to be saved, got %+v", schema)
+	}
+
+	changes, err := plane.RegisterOrUpdateService(update, false)
+	if err != nil || len(breakingChanges(changes)) != 1 || update.Version != 2 {
+		t.Errorf("expected a forced update to be saved as version 2, got changes %+v, version %d, err %v", changes, update.Version, err)
+	}
+}
+
+func cloneSchema(schema ServiceSchema) ServiceSchema {
+	clone := func(spec Spec) Spec {
+		properties := make(Properties, len(spec.Properties))
+		for name, property := range spec.Properties {
+			properties[name] = property
+		}
+		spec.Properties = properties
+		spec.Required = append([]string(nil), spec.Required...)
+		return spec
+	}
+	return ServiceSchema{Input: clone(schema.Input), Output: clone(schema.Output)}
+}
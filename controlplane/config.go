@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -19,16 +20,57 @@ const (
 )
 
 var (
-	LogsRetentionPeriod       = time.Hour * 24
-	MaxQueueSize              = 1000
-	DependencyPattern         = regexp.MustCompile(`^\$([^.]+)\.`)
-	WSWriteTimeOut            = time.Second * 120
-	WSMaxMessageBytes   int64 = 10 * 1024 // 10K
+	LogsRetentionPeriod               = time.Hour * 24
+	MaxQueueSize                      = 1000
+	DependencyPattern                 = regexp.MustCompile(`^\$([^.]+)\.`)
+	WSWriteTimeOut                    = time.Second * 120
+	WSMaxMessageBytes           int64 = 10 * 1024 // 10K
+	StoreOpenTimeout                  = time.Second * 5
+	LogSegmentMaxBytes          int64 = 1024 * 1024 // 1MB
+	DefaultPageSize                   = 20
+	MaxPageSize                       = 100
+	LogStreamPollInterval             = time.Millisecond * 500
+	LogStreamKeepAlive                = time.Second * 15
+	APIKeyLastUsedResolution          = time.Minute
+	WebhookTimeout                    = time.Second * 10
+	WebhookMaxAttempts                = 8
+	WebhookRetryBaseDelay             = time.Second * 5
+	WebhookRetryMaxDelay              = time.Minute * 30
+	WebhookDeliveryPollInterval       = time.Second * 30
+	WebhookDeliveryWorkers            = 16
+	WebhookResponseSnippetBytes int64 = 512
+	PlanningTimeout                   = time.Minute * 2
+	MaxPlanRepairAttempts             = 2
+	PlanCacheTTL                      = time.Hour
+	PlanCacheMaxEntries               = 1000
+	PatternCacheMaxEntries            = 1000
 )
 
 type Config struct {
-	Port       int `envconfig:"default=8005"`
-	OpenApiKey string
+	Port        int    `envconfig:"default=8005"`
+	OpenApiKey  string `envconfig:"optional"`
+	StorageType string `envconfig:"default=bolt"`
+	DataDir     string `envconfig:"default=.orra-data"`
+	Version     string `envconfig:"default=dev"`
+	AdminKey    string `envconfig:"optional"`
+	// LLMProvider plans orchestrations: openai, anthropic, openai-compatible or fake
+	LLMProvider     string `envconfig:"default=openai"`
+	LLMModel        string `envconfig:"optional"`
+	LLMBaseURL      string `envconfig:"optional"`
+	AnthropicApiKey string `envconfig:"optional"`
+	// LLMStructuredOutput asks providers for replies conforming to the plan's JSON schema, it can be
+	// turned off for OpenAI-compatible endpoints without json_schema support
+	LLMStructuredOutput bool `envconfig:"default=true"`
+	// StrictSchemaUpdates rejects service re-registrations with breaking schema changes unless forced
+	StrictSchemaUpdates bool `envconfig:"default=false"`
+}
+
+// LogsDir is where orchestration logs are persisted, logs are only kept in memory with memory storage.
+func (c Config) LogsDir() string {
+	if strings.EqualFold(strings.TrimSpace(c.StorageType), MemoryStorage) {
+		return ""
+	}
+	return filepath.Join(c.DataDir, "logs")
 }
 
 func Load() (Config, error) {
@@ -64,23 +106,31 @@ func (s *Status) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &val); err != nil {
 		return err
 	}
+	status, err := ParseStatus(val)
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+func ParseStatus(val string) (Status, error) {
 	switch strings.ToLower(strings.TrimSpace(val)) {
 	case "registered":
-		*s = Registered
+		return Registered, nil
 	case "pending":
-		*s = Pending
+		return Pending, nil
 	case "processing":
-		*s = Processing
+		return Processing, nil
 	case "completed":
-		*s = Completed
+		return Completed, nil
 	case "failed":
-		*s = Failed
+		return Failed, nil
 	case "not-actionable":
-		*s = NotActionable
+		return NotActionable, nil
 	default:
-		return fmt.Errorf("invalid Status: %+v", s)
+		return 0, fmt.Errorf("invalid Status: %s", val)
 	}
-	return nil
 }
 
 type ServiceType int
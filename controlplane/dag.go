@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ExecutionDAG is the order a plan's tasks run in, derived from the references in the tasks' inputs.
+type ExecutionDAG struct {
+	// Dependencies lists the tasks each task waits for. Tasks that reference no other service
+	// task wait for task0, i.e. the orchestration's start.
+	Dependencies map[string][]string `json:"dependencies"`
+	// Levels groups tasks by their depth in the DAG, tasks in the same level never depend on each other
+	Levels []ParallelGroup `json:"levels"`
+	// CriticalPath is the longest chain of dependent tasks, it bounds how quickly the plan can complete
+	CriticalPath []string `json:"critical_path"`
+}
+
+// newExecutionDAG computes the plan's DAG, it fails when the plan's tasks depend on each other in a cycle.
+func newExecutionDAG(plan *ServiceCallingPlan) (*ExecutionDAG, error) {
+	tasks := make(map[string]*SubTask, len(plan.Tasks))
+	for _, subTask := range plan.Tasks {
+		tasks[subTask.ID] = subTask
+	}
+
+	if cycle := findDependencyCycle(plan, tasks); len(cycle) > 0 {
+		return nil, fmt.Errorf("tasks depend on each other in a cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	dag := &ExecutionDAG{Dependencies: make(map[string][]string, len(plan.Tasks))}
+	for _, subTask := range plan.Tasks {
+		var dependencies []string
+		for _, dependency := range sortedKeys(subTask.extractDependencies()) {
+			if _, exists := tasks[dependency]; exists || strings.EqualFold(dependency, TaskZero) {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+		if len(dependencies) == 0 {
+			dependencies = []string{TaskZero}
+		}
+		dag.Dependencies[subTask.ID] = dependencies
+	}
+
+	depths := make(map[string]int, len(plan.Tasks))
+	var depth func(taskID string) int
+	depth = func(taskID string) int {
+		if d, ok := depths[taskID]; ok {
+			return d
+		}
+		d := 0
+		for _, dependency := range dag.Dependencies[taskID] {
+			if _, exists := tasks[dependency]; exists {
+				d = max(d, depth(dependency)+1)
+			}
+		}
+		depths[taskID] = d
+		return d
+	}
+
+	var last string
+	for _, subTask := range plan.Tasks {
+		d := depth(subTask.ID)
+		for len(dag.Levels) <= d {
+			dag.Levels = append(dag.Levels, ParallelGroup{})
+		}
+		dag.Levels[d] = append(dag.Levels[d], subTask.ID)
+		if last == "" || d > depths[last] {
+			last = subTask.ID
+		}
+	}
+
+	for taskID := last; taskID != ""; {
+		dag.CriticalPath = append(dag.CriticalPath, taskID)
+		next := ""
+		for _, dependency := range dag.Dependencies[taskID] {
+			if _, exists := tasks[dependency]; exists && depths[dependency] == depths[taskID]-1 {
+				next = dependency
+				break
+			}
+		}
+		taskID = next
+	}
+	slices.Reverse(dag.CriticalPath)
+
+	return dag, nil
+}
+
+// scheduleExecution sets the plan's DAG, its tasks run in the DAG's levels. The plan's parallel groups are
+// kept as planned, the levels can run tasks the groups keep apart in parallel.
+func (plan *ServiceCallingPlan) scheduleExecution() error {
+	dag, err := newExecutionDAG(plan)
+	if err != nil {
+		return err
+	}
+
+	plan.DAG = dag
+	return nil
+}
+
+func (d *ExecutionDAG) dependencyKeys(taskID string) DependencyKeys {
+	out := make(DependencyKeys)
+	for _, dependency := range d.Dependencies[taskID] {
+		out[dependency] = struct{}{}
+	}
+	return out
+}
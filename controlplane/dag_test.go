@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScheduleExecutionDerivesDAGAndKeepsParallelGroups(t *testing.T) {
+	plan := &ServiceCallingPlan{
+		Tasks: []*SubTask{
+			{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+			{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+			{ID: "task3", Service: "s2", Input: map[string]Source{"message": "$task1.echo", "count": "$task0.count"}},
+			{ID: "task4", Service: "s1", Input: map[string]Source{"a": "$task2.echo", "b": "$task3.echo"}},
+			{ID: "task5", Service: "s2", Input: map[string]Source{"message": "hello"}},
+		},
+		ParallelGroups: []ParallelGroup{{"task1"}, {"task2"}, {"task3"}, {"task4", "task5"}},
+	}
+
+	if err := plan.scheduleExecution(); err != nil {
+		t.Fatalf("failed to schedule plan: %v", err)
+	}
+
+	if want := []ParallelGroup{{"task1", "task5"}, {"task2", "task3"}, {"task4"}}; !reflect.DeepEqual(plan.DAG.Levels, want) {
+		t.Errorf("got levels %v, want %v", plan.DAG.Levels, want)
+	}
+	if want := []ParallelGroup{{"task1"}, {"task2"}, {"task3"}, {"task4", "task5"}}; !reflect.DeepEqual(plan.ParallelGroups, want) {
+		t.Errorf("expected the planned parallel groups to be kept, got %v", plan.ParallelGroups)
+	}
+	if want := []string{"task1", "task2", "task4"}; !reflect.DeepEqual(plan.DAG.CriticalPath, want) {
+		t.Errorf("got critical path %v, want %v", plan.DAG.CriticalPath, want)
+	}
+	wantDependencies := map[string][]string{
+		"task1": {"task0"},
+		"task2": {"task1"},
+		"task3": {"task0", "task1"},
+		"task4": {"task2", "task3"},
+		"task5": {"task0"},
+	}
+	if !reflect.DeepEqual(plan.DAG.Dependencies, wantDependencies) {
+		t.Errorf("got dependencies %v, want %v", plan.DAG.Dependencies, wantDependencies)
+	}
+
+	cyclic := &ServiceCallingPlan{
+		Tasks: []*SubTask{
+			{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task2.echo"}},
+			{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+		},
+	}
+	if err := cyclic.scheduleExecution(); err == nil {
+		t.Errorf("expected a cyclic plan to fail scheduling")
+	}
+}
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const webhookSecretPrefix = "whsec-orra-"
+
+// WebhookDelivery is an event waiting to be posted to a webhook. Failed attempts are retried with an
+// exponential backoff until WebhookMaxAttempts is reached, the delivery is then dead-lettered.
+type WebhookDelivery struct {
+	ID              string          `json:"id"`
+	ProjectID       string          `json:"projectId"`
+	WebhookID       string          `json:"webhookId"`
+	URL             string          `json:"url"`
+	EventID         string          `json:"eventId"`
+	EventType       EventType       `json:"eventType"`
+	OrchestrationID string          `json:"orchestrationId"`
+	Payload         json.RawMessage `json:"payload"`
+	Attempts        int             `json:"attempts"`
+	NextAttemptAt   time.Time       `json:"nextAttemptAt"`
+	LastError       string          `json:"lastError,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	DeadAt          *time.Time      `json:"deadAt,omitempty"`
+}
+
+// DeliveryAttempt records the outcome of posting a delivery to its webhook.
+type DeliveryAttempt struct {
+	ID              string    `json:"id"`
+	DeliveryID      string    `json:"deliveryId"`
+	WebhookID       string    `json:"webhookId"`
+	OrchestrationID string    `json:"orchestrationId"`
+	EventID         string    `json:"eventId"`
+	EventType       EventType `json:"eventType"`
+	URL             string    `json:"url"`
+	Attempt         int       `json:"attempt"`
+	PayloadHash     string    `json:"payloadHash"`
+	StatusCode      int       `json:"statusCode,omitempty"`
+	LatencyMs       int64     `json:"latencyMs"`
+	Response        string    `json:"response,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	AttemptedAt     time.Time `json:"attemptedAt"`
+}
+
+var ErrOrchestrationNotFinalized = errors.New("orchestration has not finished yet")
+
+func (d *WebhookDelivery) Dead() bool {
+	return d.DeadAt != nil
+}
+
+func (d *WebhookDelivery) clone() *WebhookDelivery {
+	out := *d
+	out.Payload = slices.Clone(d.Payload)
+	if d.DeadAt != nil {
+		deadAt := *d.DeadAt
+		out.DeadAt = &deadAt
+	}
+	return &out
+}
+
+// webhookDeliverer posts queued deliveries on a bounded pool of workers, its wake channel is signalled
+// whenever deliveries are queued or a worker finishes.
+type webhookDeliverer struct {
+	client *http.Client
+	wake   chan struct{}
+	mu     sync.Mutex
+	// workers holds a slot for each delivery being posted, inFlight the deliveries' ids
+	workers    chan struct{}
+	inFlight   map[string]struct{}
+	inFlightMu sync.Mutex
+	wg         sync.WaitGroup
+}
+
+func newWebhookDeliverer() *webhookDeliverer {
+	return &webhookDeliverer{
+		client:   &http.Client{Timeout: WebhookTimeout},
+		wake:     make(chan struct{}, 1),
+		workers:  make(chan struct{}, WebhookDeliveryWorkers),
+		inFlight: make(map[string]struct{}),
+	}
+}
+
+// claim marks the delivery in flight, it fails when the delivery is already being posted.
+func (d *webhookDeliverer) claim(deliveryID string) bool {
+	d.inFlightMu.Lock()
+	defer d.inFlightMu.Unlock()
+
+	if _, claimed := d.inFlight[deliveryID]; claimed {
+		return false
+	}
+	d.inFlight[deliveryID] = struct{}{}
+	return true
+}
+
+func (d *webhookDeliverer) release(deliveryID string) {
+	d.inFlightMu.Lock()
+	defer d.inFlightMu.Unlock()
+	delete(d.inFlight, deliveryID)
+}
+
+// StartWebhookDelivery keeps delivering queued webhook events until the context is done, deliveries
+// persisted before a restart are picked up straight away.
+func (p *ControlPlane) StartWebhookDelivery(ctx context.Context) {
+	go func() {
+		for {
+			next := p.DeliverDueWebhooks(ctx)
+
+			wait := WebhookDeliveryPollInterval
+			if !next.IsZero() {
+				wait = min(max(time.Until(next), 0), WebhookDeliveryPollInterval)
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-p.deliverer.wake:
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// DeliverDueWebhooks hands every pending delivery that is due to a worker and returns when the next one is
+// due, or the zero time if nothing else is pending. It doesn't wait for the deliveries to be posted, once
+// every worker is busy the remaining deliveries are picked up as workers finish.
+func (p *ControlPlane) DeliverDueWebhooks(ctx context.Context) time.Time {
+	due, next, err := p.store.ListDueWebhookDeliveries(time.Now())
+	if err != nil {
+		p.Logger.Error().Err(err).Msg("Failed to load pending webhook deliveries")
+		return time.Now().Add(WebhookDeliveryPollInterval)
+	}
+
+	for _, delivery := range due {
+		if !p.deliverer.claim(delivery.ID) {
+			continue
+		}
+
+		select {
+		case p.deliverer.workers <- struct{}{}:
+		default:
+			p.deliverer.release(delivery.ID)
+			return next
+		}
+
+		p.deliverer.wg.Add(1)
+		go func(delivery *WebhookDelivery) {
+			defer func() {
+				<-p.deliverer.workers
+				p.deliverer.release(delivery.ID)
+				p.deliverer.wg.Done()
+				// Retries rescheduled by the attempt and deliveries left waiting for a worker are picked up
+				p.wakeWebhookDelivery()
+			}()
+			p.attemptDelivery(ctx, delivery)
+		}(delivery)
+	}
+
+	return next
+}
+
+// ListDeadLetters returns the project's deliveries that ran out of attempts, most recent first.
+func (p *ControlPlane) ListDeadLetters(projectID string) ([]*WebhookDelivery, error) {
+	deliveries, err := p.store.ListWebhookDeliveries(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook deliveries for project %s: %w", projectID, err)
+	}
+
+	out := make([]*WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		if delivery.Dead() {
+			out = append(out, delivery)
+		}
+	}
+	slices.SortFunc(out, func(a, b *WebhookDelivery) int {
+		return b.DeadAt.Compare(*a.DeadAt)
+	})
+	return out, nil
+}
+
+// RedriveDeadLetters queues the project's dead-lettered deliveries again with a fresh set of attempts,
+// either the ones with the given ids or all of them when none are given.
+func (p *ControlPlane) RedriveDeadLetters(projectID string, deliveryIDs ...string) ([]*WebhookDelivery, error) {
+	p.deliverer.mu.Lock()
+	defer p.deliverer.mu.Unlock()
+
+	deadLetters, err := p.ListDeadLetters(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range deliveryIDs {
+		if !slices.ContainsFunc(deadLetters, func(delivery *WebhookDelivery) bool { return delivery.ID == id }) {
+			return nil, fmt.Errorf("dead-lettered delivery %s for project %s: %w", id, projectID, ErrNotFound)
+		}
+	}
+
+	redriven := make([]*WebhookDelivery, 0, len(deadLetters))
+	for _, delivery := range deadLetters {
+		if len(deliveryIDs) > 0 && !slices.Contains(deliveryIDs, delivery.ID) {
+			continue
+		}
+
+		delivery.Attempts = 0
+		delivery.DeadAt = nil
+		delivery.NextAttemptAt = time.Now().UTC()
+		if err := p.store.SaveWebhookDelivery(delivery); err != nil {
+			return nil, fmt.Errorf("failed to save webhook delivery %s: %w", delivery.ID, err)
+		}
+		redriven = append(redriven, delivery)
+	}
+
+	p.Logger.Info().
+		Str("ProjectID", projectID).
+		Int("Deliveries", len(redriven)).
+		Msg("Redriving dead-lettered webhook deliveries")
+
+	p.wakeWebhookDelivery()
+	return redriven, nil
+}
+
+// ListDeliveryAttempts returns every attempt to deliver the orchestration's events, oldest first.
+func (p *ControlPlane) ListDeliveryAttempts(projectID, orchestrationID string) ([]*DeliveryAttempt, error) {
+	if _, err := p.GetProjectOrchestration(projectID, orchestrationID); err != nil {
+		return nil, err
+	}
+
+	attempts, err := p.store.ListDeliveryAttempts(orchestrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delivery attempts for orchestration %s: %w", orchestrationID, err)
+	}
+
+	slices.SortFunc(attempts, func(a, b *DeliveryAttempt) int {
+		return a.AttemptedAt.Compare(b.AttemptedAt)
+	})
+	if attempts == nil {
+		attempts = []*DeliveryAttempt{}
+	}
+	return attempts, nil
+}
+
+// RedeliverOrchestration queues the finished orchestration's result again for the project's webhooks subscribed
+// to it, or only for the given webhook whatever its subscriptions.
+func (p *ControlPlane) RedeliverOrchestration(projectID, orchestrationID, webhookID string) ([]*WebhookDelivery, error) {
+	orchestration, err := p.GetProjectOrchestration(projectID, orchestrationID)
+	if err != nil {
+		return nil, err
+	}
+	if orchestration.Status != Completed && orchestration.Status != Failed && orchestration.Status != NotActionable {
+		return nil, fmt.Errorf("cannot redeliver orchestration %s: %w", orchestrationID, ErrOrchestrationNotFinalized)
+	}
+
+	project, err := p.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := newOrchestrationEvent(orchestration)
+	var webhooks []*Webhook
+	for _, webhook := range project.Webhooks {
+		if webhook.ID == webhookID || (webhookID == "" && webhook.Subscribes(event.Type)) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	if webhookID != "" && len(webhooks) == 0 {
+		return nil, fmt.Errorf("webhook %s for project %s: %w", webhookID, projectID, ErrNotFound)
+	}
+
+	p.Logger.Info().
+		Str("OrchestrationID", orchestrationID).
+		Int("Webhooks", len(webhooks)).
+		Msg("Redelivering orchestration result")
+
+	return p.queueDeliveries(projectID, event, webhooks)
+}
+
+// GetWebhookSecret returns the secret the project's webhook payloads are signed with.
+func (p *ControlPlane) GetWebhookSecret(projectID string) (string, error) {
+	project, err := p.GetProject(projectID)
+	if err != nil {
+		return "", err
+	}
+	return project.WebhookSecret, nil
+}
+
+// RotateWebhookSecret replaces the project's webhook secret, deliveries are signed with the new one from now on.
+func (p *ControlPlane) RotateWebhookSecret(projectID string) (string, error) {
+	p.projectsMu.Lock()
+	defer p.projectsMu.Unlock()
+
+	project, err := p.store.GetProject(projectID)
+	if err != nil {
+		return "", err
+	}
+
+	if project.WebhookSecret, err = generateWebhookSecret(); err != nil {
+		return "", err
+	}
+	if err := p.store.SaveProject(project); err != nil {
+		return "", fmt.Errorf("failed to save project %s: %w", project.ID, err)
+	}
+
+	p.Logger.Info().Str("ProjectID", projectID).Msg("Rotated webhook secret")
+	return project.WebhookSecret, nil
+}
+
+// queueDeliveries persists a delivery of the event for each webhook and wakes up the deliverer.
+func (p *ControlPlane) queueDeliveries(projectID string, event *WebhookEvent, webhooks []*Webhook) ([]*WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event payload: %w", event.Type, err)
+	}
+
+	p.deliverer.mu.Lock()
+	defer p.deliverer.mu.Unlock()
+
+	now := time.Now().UTC()
+	queued := make([]*WebhookDelivery, 0, len(webhooks))
+	var errs []error
+	for _, webhook := range webhooks {
+		delivery := &WebhookDelivery{
+			ID:              uuid.New().String(),
+			ProjectID:       projectID,
+			WebhookID:       webhook.ID,
+			URL:             webhook.URL,
+			EventID:         event.ID,
+			EventType:       event.Type,
+			OrchestrationID: event.OrchestrationID,
+			Payload:         payload,
+			NextAttemptAt:   now,
+			CreatedAt:       now,
+		}
+		if err := p.store.SaveWebhookDelivery(delivery); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", webhook.ID, err))
+			continue
+		}
+		queued = append(queued, delivery)
+	}
+
+	p.wakeWebhookDelivery()
+	return queued, errors.Join(errs...)
+}
+
+func (p *ControlPlane) wakeWebhookDelivery() {
+	select {
+	case p.deliverer.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *ControlPlane) attemptDelivery(ctx context.Context, delivery *WebhookDelivery) {
+	start := time.Now()
+	project, err := p.store.GetProject(delivery.ProjectID)
+	if errors.Is(err, ErrNotFound) {
+		p.discardDelivery(delivery)
+		return
+	}
+
+	var statusCode int
+	var response string
+	var postErr error
+	if err != nil {
+		// Counted as a failed attempt so the delivery backs off rather than staying due
+		p.Logger.Error().Err(err).Str("DeliveryID", delivery.ID).Msg("Failed to load project for webhook delivery")
+		postErr = fmt.Errorf("failed to load project: %w", err)
+	} else {
+		p.Logger.Debug().
+			Str("OrchestrationID", delivery.OrchestrationID).
+			Str("ProjectID", delivery.ProjectID).
+			Str("Webhook", delivery.URL).
+			Str("Event", string(delivery.EventType)).
+			Int("Attempt", delivery.Attempts+1).
+			Msg("Triggering webhook")
+
+		statusCode, response, postErr = p.postWebhook(ctx, delivery, project.WebhookSecret)
+	}
+
+	p.deliverer.mu.Lock()
+	defer p.deliverer.mu.Unlock()
+
+	// The delivery may have been removed along with its project while it was being posted
+	if _, err := p.store.GetWebhookDelivery(delivery.ID); err != nil {
+		return
+	}
+
+	delivery.Attempts++
+	p.recordDeliveryAttempt(delivery, start, statusCode, response, postErr)
+	if postErr == nil {
+		if err := p.store.DeleteWebhookDelivery(delivery.ID); err != nil {
+			p.Logger.Error().Err(err).Str("DeliveryID", delivery.ID).Msg("Failed to remove completed webhook delivery")
+		}
+		return
+	}
+
+	delivery.LastError = postErr.Error()
+	now := time.Now().UTC()
+	if delivery.Attempts >= WebhookMaxAttempts {
+		delivery.DeadAt = &now
+		p.Logger.Warn().
+			Err(postErr).
+			Str("DeliveryID", delivery.ID).
+			Str("OrchestrationID", delivery.OrchestrationID).
+			Str("Webhook", delivery.URL).
+			Msgf("Webhook delivery failed after %d attempts, moved to dead letters", delivery.Attempts)
+	} else {
+		delivery.NextAttemptAt = now.Add(webhookRetryDelay(delivery.Attempts))
+		p.Logger.Debug().
+			Err(postErr).
+			Str("DeliveryID", delivery.ID).
+			Time("NextAttemptAt", delivery.NextAttemptAt).
+			Msg("Webhook delivery failed, retrying later")
+	}
+
+	if err := p.store.SaveWebhookDelivery(delivery); err != nil {
+		p.Logger.Error().Err(err).Str("DeliveryID", delivery.ID).Msg("Failed to save webhook delivery")
+	}
+}
+
+// recordDeliveryAttempt keeps the attempt in the orchestration's delivery history, callers should hold the deliverer's lock.
+func (p *ControlPlane) recordDeliveryAttempt(delivery *WebhookDelivery, start time.Time, statusCode int, response string, postErr error) {
+	payloadHash := sha256.Sum256(delivery.Payload)
+	attempt := &DeliveryAttempt{
+		ID:              uuid.New().String(),
+		DeliveryID:      delivery.ID,
+		WebhookID:       delivery.WebhookID,
+		OrchestrationID: delivery.OrchestrationID,
+		EventID:         delivery.EventID,
+		EventType:       delivery.EventType,
+		URL:             delivery.URL,
+		Attempt:         delivery.Attempts,
+		PayloadHash:     "sha256:" + hex.EncodeToString(payloadHash[:]),
+		StatusCode:      statusCode,
+		LatencyMs:       time.Since(start).Milliseconds(),
+		Response:        response,
+		AttemptedAt:     start.UTC(),
+	}
+	if postErr != nil {
+		attempt.Error = postErr.Error()
+	}
+
+	if err := p.store.SaveDeliveryAttempt(attempt); err != nil {
+		p.Logger.Error().Err(err).Str("DeliveryID", delivery.ID).Msg("Failed to record webhook delivery attempt")
+	}
+}
+
+func (p *ControlPlane) discardDelivery(delivery *WebhookDelivery) {
+	p.deliverer.mu.Lock()
+	defer p.deliverer.mu.Unlock()
+
+	if err := p.store.DeleteWebhookDelivery(delivery.ID); err != nil && !errors.Is(err, ErrNotFound) {
+		p.Logger.Error().Err(err).Str("DeliveryID", delivery.ID).Msg("Failed to discard webhook delivery")
+	}
+}
+
+// postWebhook returns the response's status code and the start of its body along with any error.
+func (p *ControlPlane) postWebhook(ctx context.Context, delivery *WebhookDelivery, secret string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Orra/1.0")
+	req.Header.Set("X-Orra-Delivery", delivery.ID)
+	req.Header.Set("X-Orra-Event", string(delivery.EventType))
+	req.Header.Set("X-Orra-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Orra-Signature", SignWebhookPayload(secret, timestamp, delivery.Payload))
+
+	resp, err := p.deliverer.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			p.Logger.Error().Err(err).Str("DeliveryID", delivery.ID).Msg("Failed to close webhook response body")
+		}
+	}(resp.Body)
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, WebhookResponseSnippetBytes))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(snippet), fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(snippet), nil
+}
+
+// SignWebhookPayload returns the X-Orra-Signature header value, the hex encoded HMAC-SHA256 of
+// "<timestamp>.<payload>" keyed with the project's webhook secret.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay doubles the delay after each failed attempt, up to WebhookRetryMaxDelay.
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := WebhookRetryBaseDelay
+	for i := 1; i < attempts && delay < WebhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, WebhookRetryMaxDelay)
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(secret), nil
+}
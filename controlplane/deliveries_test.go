@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestWebhookDeliveryRetriesThenDeadLettersUntilRedriven(t *testing.T) {
+	defer func(original time.Duration) { WebhookRetryBaseDelay = original }(WebhookRetryBaseDelay)
+	WebhookRetryBaseDelay = 0
+	defer func(original int) { WebhookMaxAttempts = original }(WebhookMaxAttempts)
+	WebhookMaxAttempts = 3
+
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	signatures := make(chan bool, 10)
+	plane, store := newTestPlane(t, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		project, _ := store.GetProject("p1")
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Orra-Timestamp"), 10, 64)
+		signatures <- r.Header.Get("X-Orra-Signature") == SignWebhookPayload(project.WebhookSecret, timestamp, body)
+
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	project := &Project{ID: "p1", Webhook: server.URL}
+	if err := plane.RegisterProject(project); err != nil {
+		t.Fatalf("failed to register project: %v", err)
+	}
+
+	orchestration := &Orchestration{ID: "o1", Status: Failed, Error: json.RawMessage(`"boom"`)}
+	if err := plane.DispatchEvent("p1", newOrchestrationEvent(orchestration)); err != nil {
+		t.Fatalf("failed to dispatch event: %v", err)
+	}
+
+	for i := 0; i < WebhookMaxAttempts; i++ {
+		deliverDueWebhooks(plane)
+	}
+	if calls.Load() != int32(WebhookMaxAttempts) {
+		t.Fatalf("expected %d attempts, got %d", WebhookMaxAttempts, calls.Load())
+	}
+
+	deadLetters, err := plane.ListDeadLetters("p1")
+	if err != nil || len(deadLetters) != 1 {
+		t.Fatalf("expected a dead-lettered delivery, got %+v, err %v", deadLetters, err)
+	}
+	if deadLetters[0].Attempts != WebhookMaxAttempts || deadLetters[0].LastError == "" {
+		t.Errorf("unexpected dead-lettered delivery: %+v", deadLetters[0])
+	}
+
+	// Dead letters are no longer attempted
+	deliverDueWebhooks(plane)
+	if calls.Load() != int32(WebhookMaxAttempts) {
+		t.Errorf("dead-lettered delivery was attempted again")
+	}
+
+	healthy.Store(true)
+	if _, err := plane.RedriveDeadLetters("p1", "missing"); err == nil {
+		t.Errorf("expected redriving an unknown delivery to fail")
+	}
+	if redriven, err := plane.RedriveDeadLetters("p1"); err != nil || len(redriven) != 1 {
+		t.Fatalf("failed to redrive dead letters: %+v, %v", redriven, err)
+	}
+	deliverDueWebhooks(plane)
+
+	if pending, _ := store.ListWebhookDeliveries("p1"); len(pending) != 0 {
+		t.Errorf("expected the redriven delivery to be completed, got %+v", pending)
+	}
+
+	close(signatures)
+	for valid := range signatures {
+		if !valid {
+			t.Errorf("expected every attempt to be signed with the project's webhook secret")
+		}
+	}
+}
+
+func TestWebhookRetryDelayBacksOffExponentially(t *testing.T) {
+	want := []time.Duration{WebhookRetryBaseDelay, 2 * WebhookRetryBaseDelay, 4 * WebhookRetryBaseDelay}
+	for i, delay := range want {
+		if got := webhookRetryDelay(i + 1); got != delay {
+			t.Errorf("attempt %d: got delay %s, want %s", i+1, got, delay)
+		}
+	}
+	if got := webhookRetryDelay(100); got != WebhookRetryMaxDelay {
+		t.Errorf("got delay %s, want it capped at %s", got, WebhookRetryMaxDelay)
+	}
+}
+
+func TestDeliveryAttemptsAreRecordedAndRedelivered(t *testing.T) {
+	defer func(original time.Duration) { WebhookRetryBaseDelay = original }(WebhookRetryBaseDelay)
+	WebhookRetryBaseDelay = 0
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("upstream unavailable"))
+		}
+	}))
+	defer server.Close()
+
+	plane, store := newTestPlane(t, nil)
+	if err := plane.RegisterProject(&Project{ID: "p1", Webhook: server.URL}); err != nil {
+		t.Fatalf("failed to register project: %v", err)
+	}
+	orchestration := &Orchestration{ID: "o1", ProjectID: "p1", Status: Processing}
+	_ = store.SaveOrchestration(orchestration)
+
+	if _, err := plane.RedeliverOrchestration("p1", "o1", ""); !errors.Is(err, ErrOrchestrationNotFinalized) {
+		t.Errorf("expected redelivering a running orchestration to fail, got %v", err)
+	}
+
+	orchestration.Status = Completed
+	orchestration.Results = []json.RawMessage{json.RawMessage(`{"echo":"hi"}`)}
+	_ = store.SaveOrchestration(orchestration)
+	if err := plane.DispatchEvent("p1", newOrchestrationEvent(orchestration)); err != nil {
+		t.Fatalf("failed to dispatch event: %v", err)
+	}
+
+	deliverDueWebhooks(plane)
+	deliverDueWebhooks(plane)
+
+	attempts, err := plane.ListDeliveryAttempts("p1", "o1")
+	if err != nil || len(attempts) != 2 {
+		t.Fatalf("expected 2 delivery attempts, got %+v, err %v", attempts, err)
+	}
+	failed, succeeded := attempts[0], attempts[1]
+	if failed.StatusCode != http.StatusBadGateway || failed.Response != "upstream unavailable" || failed.Error == "" {
+		t.Errorf("unexpected failed attempt: %+v", failed)
+	}
+	if succeeded.StatusCode != http.StatusOK || succeeded.Attempt != 2 || succeeded.PayloadHash != failed.PayloadHash {
+		t.Errorf("unexpected successful attempt: %+v", succeeded)
+	}
+
+	if _, err := plane.RedeliverOrchestration("p1", "o1", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected redelivering to an unknown webhook to fail, got %v", err)
+	}
+	redelivered, err := plane.RedeliverOrchestration("p1", "o1", "")
+	if err != nil || len(redelivered) != 1 {
+		t.Fatalf("failed to redeliver orchestration: %+v, %v", redelivered, err)
+	}
+	deliverDueWebhooks(plane)
+
+	if attempts, _ := plane.ListDeliveryAttempts("p1", "o1"); len(attempts) != 3 || attempts[2].DeliveryID != redelivered[0].ID {
+		t.Errorf("expected the redelivery to be recorded, got %+v", attempts)
+	}
+}
+
+func TestDeliverDueWebhooksDoesNotWaitForSlowWebhooks(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	plane, store := newTestPlane(t, nil)
+	if err := plane.RegisterProject(&Project{ID: "p1", Webhook: server.URL}); err != nil {
+		t.Fatalf("failed to register project: %v", err)
+	}
+	if err := plane.DispatchEvent("p1", newOrchestrationEvent(&Orchestration{ID: "o1", Status: Completed})); err != nil {
+		t.Fatalf("failed to dispatch event: %v", err)
+	}
+
+	returned := make(chan struct{})
+	go func() {
+		plane.DeliverDueWebhooks(context.Background())
+		// The delivery still being posted is not handed to another worker
+		plane.DeliverDueWebhooks(context.Background())
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("delivering due webhooks waited for the slow webhook")
+	}
+
+	close(release)
+	plane.deliverer.wg.Wait()
+	if pending, _ := store.ListWebhookDeliveries("p1"); len(pending) != 0 {
+		t.Errorf("expected the delivery to be completed, got %+v", pending)
+	}
+	if attempts, _ := store.ListDeliveryAttempts("o1"); len(attempts) != 1 {
+		t.Errorf("expected a single attempt, got %+v", attempts)
+	}
+}
+
+// unreadableProjectsStore fails to load projects once broken.
+type unreadableProjectsStore struct {
+	*MemoryStore
+	broken atomic.Bool
+}
+
+func (s *unreadableProjectsStore) GetProject(id string) (*Project, error) {
+	if s.broken.Load() {
+		return nil, errors.New("store unavailable")
+	}
+	return s.MemoryStore.GetProject(id)
+}
+
+func TestWebhookDeliveryBacksOffWhenItsProjectCannotBeLoaded(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	store := &unreadableProjectsStore{MemoryStore: NewMemoryStore()}
+	plane := NewControlPlane(nil, store)
+	plane.Logger = zerolog.Nop()
+	if err := plane.RegisterProject(&Project{ID: "p1", Webhook: server.URL}); err != nil {
+		t.Fatalf("failed to register project: %v", err)
+	}
+	if err := plane.DispatchEvent("p1", newOrchestrationEvent(&Orchestration{ID: "o1", Status: Completed})); err != nil {
+		t.Fatalf("failed to dispatch event: %v", err)
+	}
+
+	store.broken.Store(true)
+	deliverDueWebhooks(plane)
+
+	pending, _ := store.ListWebhookDeliveries("p1")
+	if len(pending) != 1 || pending[0].Attempts != 1 || !pending[0].NextAttemptAt.After(time.Now()) {
+		t.Fatalf("expected the delivery to be retried later, got %+v", pending)
+	}
+	if due, _, _ := store.ListDueWebhookDeliveries(time.Now()); len(due) != 0 {
+		t.Errorf("expected no delivery to be due straight away, got %+v", due)
+	}
+	if attempts, _ := store.ListDeliveryAttempts("o1"); len(attempts) != 1 || attempts[0].Error == "" {
+		t.Errorf("expected the failed attempt to be recorded, got %+v", attempts)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected the webhook not to be posted, got %d calls", calls.Load())
+	}
+}
@@ -108,6 +108,10 @@ func (f *FailureTracker) processEntry(entry LogEntry, orchestrationID string) er
 		return err
 	}
 
+	if entry.ID != ResultAggregatorID {
+		f.LogManager.NotifyTaskEvent(EventTaskFailed, orchestrationID, entry.ID, entry.ProducerID, entry.Value)
+	}
+
 	failed, err := f.LogManager.MarkOrchestrationFailed(orchestrationID, reason)
 	if err != nil {
 		return err
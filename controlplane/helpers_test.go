@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// echoPlan is a plan for the echo service passing the action's message through task0.
+const echoPlan = `{"tasks":[{"id":"task0","input":{"message":"hi"}},{"id":"task1","service":"s1","input":{"message":"$task0.message"}}]}`
+
+// newTestPlane returns a control plane over a memory store, discarding its logs, with the services saved to
+// the store. Services without a project belong to project p1.
+func newTestPlane(t *testing.T, llm LLMProvider, services ...*ServiceInfo) (*ControlPlane, *MemoryStore) {
+	t.Helper()
+
+	store := NewMemoryStore()
+	for _, service := range services {
+		if service.ProjectID == "" {
+			service.ProjectID = "p1"
+		}
+		if err := store.SaveService(service); err != nil {
+			t.Fatalf("failed to save service %s: %v", service.ID, err)
+		}
+	}
+
+	plane := NewControlPlane(llm, store)
+	plane.Logger = zerolog.Nop()
+	return plane, store
+}
+
+// startTestPlane gives the plane a WebSocket manager and a LogManager persisting logs to the directory so
+// orchestrations can execute, tasks are queued as no service is connected.
+func startTestPlane(t *testing.T, plane *ControlPlane, logsDir string) context.Context {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	plane.WebSocketManager = NewWebSocketManager(zerolog.Nop())
+	plane.LogManager = NewLogManager(ctx, logsDir, LogsRetentionPeriod, plane)
+	plane.LogManager.Logger = zerolog.Nop()
+	return ctx
+}
+
+// echoService echoes the message it receives.
+func echoService() *ServiceInfo {
+	return &ServiceInfo{
+		ID:      "s1",
+		Name:    "echo",
+		Version: 1,
+		Schema: ServiceSchema{
+			Input:  Spec{Type: "object", Properties: Properties{"message": {Type: "string"}}},
+			Output: Spec{Type: "object", Properties: Properties{"echo": {Type: "string"}}},
+		},
+	}
+}
+
+// echoServiceUpdate is a new version of the echo service renaming its message input to text, a breaking change.
+func echoServiceUpdate() *ServiceInfo {
+	service := echoService()
+	service.ProjectID = "p1"
+	service.Schema.Input.Properties = Properties{"text": {Type: "string"}}
+	return service
+}
+
+// echoOrchestration is a pending orchestration of project p1 asking to echo the message.
+func echoOrchestration(id, message string) *Orchestration {
+	return &Orchestration{
+		ID:        id,
+		ProjectID: "p1",
+		Action:    Action{Type: "echo", Content: "Echo this"},
+		Params:    ActionParams{{Field: "message", Value: message}},
+		Status:    Pending,
+	}
+}
+
+// queuedTask is a task sent to a service that is not connected.
+type queuedTask struct {
+	ID          string          `json:"id"`
+	ExecutionID string          `json:"executionId"`
+	Input       json.RawMessage `json:"input"`
+}
+
+// waitForQueuedTask waits for the nth task queued for the service.
+func waitForQueuedTask(t *testing.T, wsm *WebSocketManager, serviceID string, n int) queuedTask {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		wsm.messageQueuesMu.RLock()
+		queue, exists := wsm.messageQueues[serviceID]
+		wsm.messageQueuesMu.RUnlock()
+		if !exists {
+			continue
+		}
+
+		queue.mu.Lock()
+		element := queue.Front()
+		for i := 0; i < n && element != nil; i++ {
+			element = element.Next()
+		}
+		queue.mu.Unlock()
+		if element == nil {
+			continue
+		}
+
+		var task queuedTask
+		if err := json.Unmarshal(element.Value.(*WebSocketQueuedMessage).Message, &task); err != nil {
+			t.Fatalf("failed to decode queued task: %v", err)
+		}
+		return task
+	}
+
+	t.Fatalf("no task %d was queued for service %s", n, serviceID)
+	return queuedTask{}
+}
+
+// deliverDueWebhooks hands the due deliveries to the deliverer's workers and waits for them to be posted.
+func deliverDueWebhooks(plane *ControlPlane) {
+	plane.DeliverDueWebhooks(context.Background())
+	plane.deliverer.wg.Wait()
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
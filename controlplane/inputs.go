@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalJSON accepts any JSON value, constants that are not strings, e.g. 5 or true, keep their JSON text
+// and are converted to the type the service declares when the task runs.
+func (s *Source) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = Source(text)
+		return nil
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, data); err != nil {
+		return err
+	}
+	*s = Source(compacted.String())
+	return nil
+}
+
+// UnmarshalJSON accepts any JSON value for the param's value, like a Source.
+func (a *ActionParam) UnmarshalJSON(data []byte) error {
+	var param struct {
+		Field string `json:"field"`
+		Value Source `json:"value"`
+	}
+	if err := json.Unmarshal(data, &param); err != nil {
+		return err
+	}
+
+	a.Field, a.Value = param.Field, string(param.Value)
+	return nil
+}
+
+// resolveTaskInput builds a task's input object from its declared input fields. Each field is either a
+// literal constant or a reference to a dependency's output, "$taskId.path", where the path selects nested
+// fields with dots and array elements with indexes, e.g. "$task1.orders[0].address.city". Action params and
+// constants are strings, they are converted to the type the spec declares for their field.
+func resolveTaskInput(input map[string]Source, outputs DependencyState, spec Spec) (json.RawMessage, error) {
+	resolved := make(map[string]any, len(input))
+	for _, field := range sortedKeys(input) {
+		source := string(input[field])
+		taskID := extractDependencyID(source)
+		if taskID == "" {
+			resolved[field] = spec.Properties[field].coerce(source)
+			continue
+		}
+
+		output, ok := outputs[taskID]
+		if !ok {
+			return nil, fmt.Errorf("input %s references %s but there is no output for task %s", field, source, taskID)
+		}
+
+		value, err := selectPath(output, strings.TrimPrefix(source, "$"+taskID+"."))
+		if err != nil {
+			return nil, fmt.Errorf("input %s references %s but %s output %w", field, source, taskID, err)
+		}
+		resolved[field] = spec.Properties[field].coerce(value)
+	}
+
+	return json.Marshal(resolved)
+}
+
+// coerce converts a string to the spec's type when it is the text of a value of that type, e.g. "5" for an
+// integer, other values are returned as they are for validation to report.
+func (s Spec) coerce(value any) any {
+	text, ok := value.(string)
+	if !ok || s.Type == "string" || s.Type == "" {
+		return value
+	}
+	if text == "null" && s.Nullable {
+		return nil
+	}
+
+	switch s.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(text, 64); err == nil {
+			return json.Number(text)
+		}
+	case "boolean":
+		if parsed, err := strconv.ParseBool(text); err == nil {
+			return parsed
+		}
+	case "object", "array":
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		var parsed any
+		if decoder.Decode(&parsed) == nil && jsonType(parsed) == s.Type {
+			return parsed
+		}
+	}
+	return value
+}
+
+type pathStep struct {
+	field string
+	index int
+}
+
+// selectPath returns the value at the path in a JSON document, e.g. "address.city" or "items[0].name".
+func selectPath(document json.RawMessage, path string) (any, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("is not valid JSON: %v", err)
+	}
+
+	selected := ""
+	for _, step := range steps {
+		if step.field != "" {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s is not an object", describePath(selected))
+			}
+			if value, ok = object[step.field]; !ok {
+				return nil, fmt.Errorf("%s has no %s field", describePath(selected), step.field)
+			}
+			selected = strings.TrimPrefix(selected+"."+step.field, ".")
+			continue
+		}
+
+		array, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an array", describePath(selected))
+		}
+		if step.index >= len(array) {
+			return nil, fmt.Errorf("%s has no element %d, it has %d", describePath(selected), step.index, len(array))
+		}
+		value = array[step.index]
+		selected = fmt.Sprintf("%s[%d]", selected, step.index)
+	}
+
+	return value, nil
+}
+
+// parsePath splits a path into its field and array index steps.
+func parsePath(path string) ([]pathStep, error) {
+	var steps []pathStep
+	for rest := path; ; {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("has a malformed path %q", path)
+		}
+		steps = append(steps, pathStep{field: rest[:end]})
+		rest = rest[end:]
+
+		for strings.HasPrefix(rest, "[") {
+			closing := strings.IndexByte(rest, ']')
+			if closing < 0 {
+				return nil, fmt.Errorf("has a malformed path %q", path)
+			}
+			index, err := strconv.Atoi(rest[1:closing])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("has a malformed path %q", path)
+			}
+			steps = append(steps, pathStep{index: index})
+			rest = rest[closing+1:]
+		}
+
+		if rest == "" {
+			return steps, nil
+		}
+		if !strings.HasPrefix(rest, ".") {
+			return nil, fmt.Errorf("has a malformed path %q", path)
+		}
+		rest = rest[1:]
+	}
+}
+
+func describePath(selected string) string {
+	if selected == "" {
+		return "the output"
+	}
+	return selected
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResolveTaskInput(t *testing.T) {
+	outputs := DependencyState{
+		"task0": json.RawMessage(`{"customerId":"c1","secret":"s","limit":"5","vip":"true","tags":"[\"a\"]"}`),
+		"task1": json.RawMessage(`{"address":{"city":"London","zip":null},"orders":[{"id":"o1","total":12.50},{"id":"o2","total":3}]}`),
+	}
+
+	spec := Spec{Type: "object", Properties: Properties{
+		"limit":    {Type: "integer"},
+		"total":    {Type: "number"},
+		"vip":      {Type: "boolean"},
+		"tags":     {Type: "array"},
+		"customer": {Type: "integer"},
+		"page":     {Type: "integer", Nullable: true},
+	}}
+
+	testCases := []struct {
+		name    string
+		input   map[string]Source
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "fields, nested paths, indexes and literals",
+			input: map[string]Source{"customer": "$task0.customerId", "city": "$task1.address.city", "total": "$task1.orders[0].total", "currency": "GBP"},
+			want:  `{"city":"London","currency":"GBP","customer":"c1","total":12.50}`,
+		},
+		{
+			name:  "params and constants converted to the declared types",
+			input: map[string]Source{"limit": "$task0.limit", "vip": "$task0.vip", "tags": "$task0.tags", "total": "2.5", "page": "null"},
+			want:  `{"limit":5,"page":null,"tags":["a"],"total":2.5,"vip":true}`,
+		},
+		{
+			name:  "values not of the declared type left for validation",
+			input: map[string]Source{"customer": "$task0.customerId", "total": "many"},
+			want:  `{"customer":"c1","total":"many"}`,
+		},
+		{
+			name:  "objects, arrays and nulls",
+			input: map[string]Source{"address": "$task1.address", "order": "$task1.orders[1]", "zip": "$task1.address.zip"},
+			want:  `{"address":{"city":"London","zip":null},"order":{"id":"o2","total":3},"zip":null}`,
+		},
+		{
+			name:    "missing field",
+			input:   map[string]Source{"city": "$task1.address.town"},
+			wantErr: "input city references $task1.address.town but task1 output address has no town field",
+		},
+		{
+			name:    "index out of range",
+			input:   map[string]Source{"order": "$task1.orders[2].id"},
+			wantErr: "input order references $task1.orders[2].id but task1 output orders has no element 2, it has 2",
+		},
+		{
+			name:    "not an array",
+			input:   map[string]Source{"city": "$task1.address[0]"},
+			wantErr: "input city references $task1.address[0] but task1 output address is not an array",
+		},
+		{
+			name:    "missing dependency",
+			input:   map[string]Source{"city": "$task2.city"},
+			wantErr: "input city references $task2.city but there is no output for task task2",
+		},
+		{
+			name:    "malformed path",
+			input:   map[string]Source{"order": "$task1.orders[x]"},
+			wantErr: `input order references $task1.orders[x] but task1 output has a malformed path "orders[x]"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := resolveTaskInput(tc.input, outputs, spec)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil || string(got) != tc.want {
+				t.Errorf("got %s, err %v, want %s", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestTaskInputsAcceptNonStringConstants(t *testing.T) {
+	var task SubTask
+	if err := json.Unmarshal([]byte(`{"id":"task0","input":{"limit":5,"vip":true,"tags":["a", "b"],"message":"hi"}}`), &task); err != nil {
+		t.Fatalf("failed to decode task: %v", err)
+	}
+	if task.Input["limit"] != "5" || task.Input["vip"] != "true" || task.Input["tags"] != `["a","b"]` || task.Input["message"] != "hi" {
+		t.Errorf("unexpected task input: %+v", task.Input)
+	}
+
+	var params ActionParams
+	if err := json.Unmarshal([]byte(`[{"field":"limit","value":5},{"field":"message","value":"hi"}]`), &params); err != nil {
+		t.Fatalf("failed to decode params: %v", err)
+	}
+	if params[0].Value != "5" || params[1].Value != "hi" {
+		t.Errorf("unexpected params: %+v", params)
+	}
+}
+
+func TestTypedTaskInputsReachServices(t *testing.T) {
+	service := echoService()
+	service.Schema.Input.Properties["limit"] = Spec{Type: "integer"}
+	plan := `{"tasks":[{"id":"task0","input":{"message":"hi","limit":5}},{"id":"task1","service":"s1","input":{"message":"$task0.message","limit":"$task0.limit"}}]}`
+	plane, _ := newTestPlane(t, NewFakeLLM(plan), service)
+	startTestPlane(t, plane, t.TempDir())
+
+	orchestration := echoOrchestration("o1", "hi")
+	orchestration.Params = append(orchestration.Params, ActionParam{Field: "limit", Value: "5"})
+	plane.PrepareOrchestration(orchestration)
+	if !orchestration.Executable() {
+		t.Fatalf("expected an executable orchestration, got %s: %s", orchestration.Status.String(), orchestration.Error)
+	}
+	plane.ExecuteOrchestration(orchestration)
+
+	if task := waitForQueuedTask(t, plane.WebSocketManager, "s1", 0); string(task.Input) != `{"limit":5,"message":"hi"}` {
+		t.Errorf("got task input %s, want the limit sent as an integer", task.Input)
+	}
+}
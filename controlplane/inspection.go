@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OrchestrationFilter struct {
+	Statuses []Status
+	Since    time.Time
+	Until    time.Time
+	Cursor   string
+	Limit    int
+}
+
+type OrchestrationPage struct {
+	Orchestrations []*OrchestrationSummary `json:"orchestrations"`
+	NextCursor     string                  `json:"nextCursor,omitempty"`
+}
+
+type OrchestrationSummary struct {
+	ID        string          `json:"id"`
+	Action    Action          `json:"action"`
+	Status    Status          `json:"status"`
+	Error     json.RawMessage `json:"error,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+type OrchestrationInspection struct {
+	ID               string              `json:"id"`
+	Action           Action              `json:"action"`
+	Params           ActionParams        `json:"data"`
+	Plan             *ServiceCallingPlan `json:"plan"`
+	PlanningAttempts []PlanningAttempt   `json:"planning_attempts,omitempty"`
+	PlanCache        string              `json:"plan_cache,omitempty"`
+	Tasks            []*TaskInspection   `json:"tasks"`
+	Results          []json.RawMessage   `json:"results"`
+	Status           Status              `json:"status"`
+	Error            json.RawMessage     `json:"error,omitempty"`
+	Timestamp        time.Time           `json:"timestamp"`
+}
+
+type TaskInspection struct {
+	ID      string `json:"id"`
+	Service string `json:"service"`
+	// ServiceVersion is the version of the service the task was planned with
+	ServiceVersion int64 `json:"service_version,omitempty"`
+	// Level is the task's level in the plan's execution DAG
+	Level  int    `json:"level"`
+	Status Status `json:"status"`
+}
+
+// ListOrchestrations returns a page of the project's orchestrations, newest first, matching the filter.
+func (p *ControlPlane) ListOrchestrations(projectID string, filter OrchestrationFilter) (*OrchestrationPage, error) {
+	orchestrations, err := p.store.ListOrchestrations(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load orchestrations for project %s: %w", projectID, err)
+	}
+
+	slices.SortFunc(orchestrations, func(a, b *Orchestration) int {
+		if c := b.Timestamp.Compare(a.Timestamp); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	var cursorTime time.Time
+	var cursorID string
+	if filter.Cursor != "" {
+		if cursorTime, cursorID, err = decodeCursor(filter.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	page := &OrchestrationPage{Orchestrations: make([]*OrchestrationSummary, 0, limit)}
+	for _, orchestration := range orchestrations {
+		if filter.Cursor != "" && !isAfterCursor(orchestration, cursorTime, cursorID) {
+			continue
+		}
+		if !filter.matches(orchestration) {
+			continue
+		}
+
+		if len(page.Orchestrations) == limit {
+			last := page.Orchestrations[limit-1]
+			page.NextCursor = encodeCursor(last.Timestamp, last.ID)
+			break
+		}
+
+		page.Orchestrations = append(page.Orchestrations, &OrchestrationSummary{
+			ID:        orchestration.ID,
+			Action:    orchestration.Action,
+			Status:    orchestration.Status,
+			Error:     orchestration.Error,
+			Timestamp: orchestration.Timestamp,
+		})
+	}
+
+	return page, nil
+}
+
+// InspectOrchestration returns the orchestration's details including the status of each of its tasks.
+func (p *ControlPlane) InspectOrchestration(projectID, orchestrationID string) (*OrchestrationInspection, error) {
+	orchestration, err := p.GetProjectOrchestration(projectID, orchestrationID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := p.LogManager.CompletedTasks(orchestrationID)
+	failedTaskID := failedTaskID(orchestration.Error)
+
+	inspection := &OrchestrationInspection{
+		ID:               orchestration.ID,
+		Action:           orchestration.Action,
+		Params:           orchestration.Params,
+		Plan:             orchestration.Plan,
+		PlanningAttempts: orchestration.PlanningAttempts,
+		PlanCache:        orchestration.PlanCache,
+		Results:          orchestration.Results,
+		Status:           orchestration.Status,
+		Error:            orchestration.Error,
+		Timestamp:        orchestration.Timestamp,
+	}
+
+	if orchestration.Plan == nil {
+		return inspection, nil
+	}
+
+	levels := make(map[string]int)
+	if orchestration.Plan.DAG != nil {
+		for i, level := range orchestration.Plan.DAG.Levels {
+			for _, taskID := range level {
+				levels[taskID] = i
+			}
+		}
+	}
+
+	for _, task := range orchestration.Plan.Tasks {
+		status := Pending
+		switch {
+		case completed[task.ID]:
+			status = Completed
+		case task.ID == failedTaskID:
+			status = Failed
+		case orchestration.Status == Processing && dependenciesCompleted(task, completed):
+			status = Processing
+		}
+
+		inspection.Tasks = append(inspection.Tasks, &TaskInspection{
+			ID:             task.ID,
+			Service:        task.Service,
+			ServiceVersion: task.ServiceVersion,
+			Level:          levels[task.ID],
+			Status:         status,
+		})
+	}
+
+	return inspection, nil
+}
+
+// GetProjectOrchestration returns the orchestration if it belongs to the project.
+func (p *ControlPlane) GetProjectOrchestration(projectID, orchestrationID string) (*Orchestration, error) {
+	orchestration, err := p.store.GetOrchestration(orchestrationID)
+	if err != nil {
+		return nil, err
+	}
+	if orchestration.ProjectID != projectID {
+		return nil, fmt.Errorf("orchestration %s: %w", orchestrationID, ErrNotFound)
+	}
+	return orchestration, nil
+}
+
+// OrchestrationLog returns the orchestration's Log while it's running, or its retained Log once finalized.
+// An orchestration that never executed has an empty Log, the bool reports whether the Log is active. A Log
+// that is no longer retained is reported as ErrNotFound, failing to read one is reported as is.
+func (p *ControlPlane) OrchestrationLog(projectID, orchestrationID string) (*Log, bool, error) {
+	orchestration, err := p.GetProjectOrchestration(projectID, orchestrationID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if log := p.LogManager.GetLog(orchestrationID); log != nil {
+		return log, true, nil
+	}
+
+	log, err := p.LogManager.ReadRetainedLog(orchestrationID)
+	if errors.Is(err, ErrNotFound) && (orchestration.Status == Pending || orchestration.Status == NotActionable) {
+		return &Log{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return log, false, nil
+}
+
+// CompletedTasks returns the tasks completed so far by an active or finalized orchestration.
+func (lm *LogManager) CompletedTasks(orchestrationID string) map[string]bool {
+	lm.mu.RLock()
+	state, active := lm.orchestrations[orchestrationID]
+	if active {
+		defer lm.mu.RUnlock()
+		return maps.Clone(state.CompletedTasks)
+	}
+	lm.mu.RUnlock()
+
+	state, err := lm.controlPlane.store.GetOrchestrationState(orchestrationID)
+	if err != nil {
+		return map[string]bool{}
+	}
+	return state.CompletedTasks
+}
+
+func (f OrchestrationFilter) matches(orchestration *Orchestration) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, orchestration.Status) {
+		return false
+	}
+	if !f.Since.IsZero() && orchestration.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !orchestration.Timestamp.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func dependenciesCompleted(task *SubTask, completed map[string]bool) bool {
+	for dep := range task.extractDependencies() {
+		if dep != TaskZero && !completed[dep] {
+			return false
+		}
+	}
+	return true
+}
+
+// failedTaskID extracts the failed task's ID from an orchestration error recorded by the FailureTracker.
+func failedTaskID(reason json.RawMessage) string {
+	var failure struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(reason, &failure); err != nil {
+		return ""
+	}
+	return failure.ID
+}
+
+func isAfterCursor(orchestration *Orchestration, cursorTime time.Time, cursorID string) bool {
+	if orchestration.Timestamp.Equal(cursorTime) {
+		return orchestration.ID > cursorID
+	}
+	return orchestration.Timestamp.Before(cursorTime)
+}
+
+func encodeCursor(timestamp time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d|%s", timestamp.UnixNano(), id)))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid cursor: %s", cursor)
+	}
+
+	nanos, id, found := strings.Cut(string(data), "|")
+	if !found {
+		return time.Time{}, "", fmt.Errorf("invalid cursor: %s", cursor)
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("invalid cursor: %s", cursor)
+	}
+
+	return time.Unix(0, unixNano), id, nil
+}
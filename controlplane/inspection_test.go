@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListOrchestrationsPaginatesAndFilters(t *testing.T) {
+	plane, store := newTestPlane(t, nil)
+
+	start := time.Date(2024, 9, 6, 14, 30, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		status := Completed
+		if i%2 == 1 {
+			status = Failed
+		}
+		_ = store.SaveOrchestration(&Orchestration{
+			ID:        fmt.Sprintf("o%d", i),
+			ProjectID: "p1",
+			Status:    status,
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	_ = store.SaveOrchestration(&Orchestration{ID: "other", ProjectID: "p2", Status: Completed, Timestamp: start})
+
+	var ids []string
+	filter := OrchestrationFilter{Limit: 2}
+	for {
+		page, err := plane.ListOrchestrations("p1", filter)
+		if err != nil {
+			t.Fatalf("failed to list orchestrations: %v", err)
+		}
+		for _, o := range page.Orchestrations {
+			ids = append(ids, o.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	if fmt.Sprint(ids) != "[o4 o3 o2 o1 o0]" {
+		t.Errorf("got %v, want newest first across pages", ids)
+	}
+
+	page, err := plane.ListOrchestrations("p1", OrchestrationFilter{
+		Statuses: []Status{Completed},
+		Since:    start.Add(time.Minute),
+		Until:    start.Add(4 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("failed to list orchestrations: %v", err)
+	}
+	if len(page.Orchestrations) != 1 || page.Orchestrations[0].ID != "o2" {
+		t.Errorf("got %+v, want only o2", page.Orchestrations)
+	}
+}
+
+func TestOrchestrationLogOnlyReportsMissingLogsAsNotFound(t *testing.T) {
+	plane, store := newTestPlane(t, nil)
+	logsDir := t.TempDir()
+	startTestPlane(t, plane, logsDir)
+
+	_ = store.SaveOrchestration(&Orchestration{ID: "pending", ProjectID: "p1", Status: Pending})
+	_ = store.SaveOrchestration(&Orchestration{ID: "pruned", ProjectID: "p1", Status: Completed})
+	_ = store.SaveOrchestration(&Orchestration{ID: "unreadable", ProjectID: "p1", Status: Completed})
+	if err := os.WriteFile(filepath.Join(logsDir, "unreadable"), []byte("not a log"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if log, _, err := plane.OrchestrationLog("p1", "pending"); err != nil || len(log.Entries) != 0 {
+		t.Errorf("expected a pending orchestration to have an empty log, got %+v, err %v", log, err)
+	}
+	if _, _, err := plane.OrchestrationLog("p1", "pruned"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a pruned log to be missing, got %v", err)
+	}
+	if _, _, err := plane.OrchestrationLog("p1", "unreadable"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected failing to read a log to be reported, got %v", err)
+	}
+}
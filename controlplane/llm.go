@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	OpenAIProvider           = "openai"
+	AnthropicProvider        = "anthropic"
+	OpenAICompatibleProvider = "openai-compatible"
+	FakeProvider             = "fake"
+)
+
+const (
+	DefaultOpenAIModel    = openai.GPT4oLatest
+	DefaultAnthropicModel = "claude-3-5-sonnet-latest"
+	anthropicBaseURL      = "https://api.anthropic.com/v1"
+	anthropicVersion      = "2023-06-01"
+	anthropicMaxTokens    = 4096
+)
+
+// LLMProvider completes the prompts the control plane uses to plan orchestrations.
+type LLMProvider interface {
+	Complete(ctx context.Context, request LLMRequest) (string, error)
+}
+
+// LLMRequest is a prompt with, optionally, the JSON schema the reply should conform to. Providers request
+// structured output for the schema when supported, the reply is then the JSON document itself.
+type LLMRequest struct {
+	Prompt     string
+	SchemaName string
+	Schema     json.RawMessage
+}
+
+// NewLLMProvider creates the LLMProvider selected by the config.
+func NewLLMProvider(cfg Config) (LLMProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.LLMProvider)) {
+	case OpenAIProvider:
+		if cfg.OpenApiKey == "" {
+			return nil, errors.New("the openai LLM provider requires OPEN_API_KEY")
+		}
+		clientConfig := openai.DefaultConfig(cfg.OpenApiKey)
+		if cfg.LLMBaseURL != "" {
+			clientConfig.BaseURL = cfg.LLMBaseURL
+		}
+		return NewOpenAILLM(clientConfig, withDefault(cfg.LLMModel, DefaultOpenAIModel), cfg.LLMStructuredOutput), nil
+	case OpenAICompatibleProvider:
+		if cfg.LLMBaseURL == "" || cfg.LLMModel == "" {
+			return nil, errors.New("the openai-compatible LLM provider requires LLM_BASE_URL and LLM_MODEL")
+		}
+		clientConfig := openai.DefaultConfig(cfg.OpenApiKey)
+		clientConfig.BaseURL = cfg.LLMBaseURL
+		return NewOpenAILLM(clientConfig, cfg.LLMModel, cfg.LLMStructuredOutput), nil
+	case AnthropicProvider:
+		if cfg.AnthropicApiKey == "" {
+			return nil, errors.New("the anthropic LLM provider requires ANTHROPIC_API_KEY")
+		}
+		return NewAnthropicLLM(cfg.AnthropicApiKey, withDefault(cfg.LLMModel, DefaultAnthropicModel), cfg.LLMBaseURL, cfg.LLMStructuredOutput), nil
+	case FakeProvider:
+		return NewFakeLLM(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s", cfg.LLMProvider)
+	}
+}
+
+// OpenAILLM talks to OpenAI or any endpoint implementing its chat completions API, e.g. Ollama, vLLM or LM Studio.
+// Structured output uses the json_schema response format.
+type OpenAILLM struct {
+	client     *openai.Client
+	model      string
+	structured bool
+}
+
+func NewOpenAILLM(config openai.ClientConfig, model string, structured bool) *OpenAILLM {
+	return &OpenAILLM{client: openai.NewClientWithConfig(config), model: model, structured: structured}
+}
+
+func (o *OpenAILLM) Complete(ctx context.Context, request LLMRequest) (string, error) {
+	completion := openai.ChatCompletionRequest{
+		Model: o.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: request.Prompt,
+			},
+		},
+	}
+	if o.structured && len(request.Schema) > 0 {
+		completion.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   request.SchemaName,
+				Schema: request.Schema,
+			},
+		}
+	}
+
+	resp, err := o.client.CreateChatCompletion(ctx, completion)
+	if err != nil {
+		return "", fmt.Errorf("error calling OpenAI API: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("OpenAI API returned no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// AnthropicLLM talks to Anthropic's messages API. Structured output forces the use of a tool whose
+// input schema is the requested schema, the tool's input is the reply.
+type AnthropicLLM struct {
+	apiKey     string
+	model      string
+	baseURL    string
+	structured bool
+	client     *http.Client
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicRequest struct {
+	Model      string               `json:"model"`
+	MaxTokens  int                  `json:"max_tokens"`
+	Messages   []anthropicMessage   `json:"messages"`
+	Tools      []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text,omitempty"`
+		Name  string          `json:"name,omitempty"`
+		Input json.RawMessage `json:"input,omitempty"`
+	} `json:"content"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewAnthropicLLM(apiKey, model, baseURL string, structured bool) *AnthropicLLM {
+	return &AnthropicLLM{
+		apiKey:     apiKey,
+		model:      model,
+		baseURL:    strings.TrimSuffix(withDefault(baseURL, anthropicBaseURL), "/"),
+		structured: structured,
+		client:     &http.Client{},
+	}
+}
+
+func (a *AnthropicLLM) Complete(ctx context.Context, request LLMRequest) (string, error) {
+	message := anthropicRequest{
+		Model:     a.model,
+		MaxTokens: anthropicMaxTokens,
+		Messages:  []anthropicMessage{{Role: "user", Content: request.Prompt}},
+	}
+	structured := a.structured && len(request.Schema) > 0
+	if structured {
+		message.Tools = []anthropicTool{{
+			Name:        request.SchemaName,
+			Description: "Submit the reply as JSON conforming to the input schema",
+			InputSchema: request.Schema,
+		}}
+		message.ToolChoice = &anthropicToolChoice{Type: "tool", Name: request.SchemaName}
+	}
+
+	resp, err := a.createMessage(ctx, message)
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch {
+		case structured && block.Type == "tool_use" && block.Name == request.SchemaName:
+			return string(block.Input), nil
+		case block.Type == "text":
+			text.WriteString(block.Text)
+		}
+	}
+	return text.String(), nil
+}
+
+func (a *AnthropicLLM) createMessage(ctx context.Context, request anthropicRequest) (*anthropicResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Anthropic request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Anthropic request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Key", a.apiKey)
+	req.Header.Set("Anthropic-Version", anthropicVersion)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling Anthropic API: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	var out anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("error decoding Anthropic API response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if out.Error != nil {
+			return nil, fmt.Errorf("error calling Anthropic API: %s: %s", out.Error.Type, out.Error.Message)
+		}
+		return nil, fmt.Errorf("error calling Anthropic API: unexpected status code: %d", resp.StatusCode)
+	}
+	return &out, nil
+}
+
+// FakeLLM replies with canned responses in order, repeating the last one, without any network access.
+// With no responses it always replies that the action cannot be planned.
+type FakeLLM struct {
+	responses []string
+	prompts   []string
+	mu        sync.Mutex
+}
+
+const fakeLLMResponse = `{"tasks":[{"id":"final","input":{"error":"the fake LLM provider cannot plan actions"}}]}`
+
+func NewFakeLLM(responses ...string) *FakeLLM {
+	return &FakeLLM{responses: responses}
+}
+
+func (f *FakeLLM) Complete(_ context.Context, request LLMRequest) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.prompts = append(f.prompts, request.Prompt)
+	switch {
+	case len(f.responses) == 0:
+		return fakeLLMResponse, nil
+	case len(f.prompts) <= len(f.responses):
+		return f.responses[len(f.prompts)-1], nil
+	default:
+		return f.responses[len(f.responses)-1], nil
+	}
+}
+
+// Prompts returns the prompts the fake has been asked to complete so far.
+func (f *FakeLLM) Prompts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.prompts...)
+}
+
+func withDefault(val, fallback string) string {
+	if strings.TrimSpace(val) == "" {
+		return fallback
+	}
+	return val
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestNewLLMProviderSelectsConfiguredProvider(t *testing.T) {
+	testCases := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"openai", Config{LLMProvider: "openai", OpenApiKey: "key"}, false},
+		{"openai without key", Config{LLMProvider: "openai"}, true},
+		{"compatible", Config{LLMProvider: "openai-compatible", LLMBaseURL: "http://localhost:11434/v1", LLMModel: "llama3.1"}, false},
+		{"compatible without model", Config{LLMProvider: "openai-compatible", LLMBaseURL: "http://localhost:11434/v1"}, true},
+		{"anthropic", Config{LLMProvider: "Anthropic", AnthropicApiKey: "key"}, false},
+		{"anthropic without key", Config{LLMProvider: "anthropic"}, true},
+		{"fake", Config{LLMProvider: "fake"}, false},
+		{"unknown", Config{LLMProvider: "other"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := NewLLMProvider(tc.cfg)
+			if tc.wantErr != (err != nil) || (err == nil && provider == nil) {
+				t.Errorf("got provider %T, err %v", provider, err)
+			}
+		})
+	}
+}
+
+func TestAnthropicLLMCompletesPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" || r.Header.Get("X-Api-Key") != "key" || r.Header.Get("Anthropic-Version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+			return
+		}
+
+		var request anthropicRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		if request.Model != "claude-test" || request.Messages[0].Content != "plan this" {
+			t.Errorf("unexpected request: %+v", request)
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"{\"tasks\":"},{"type":"text","text":"[]}"}]}`))
+	}))
+	defer server.Close()
+
+	content, err := NewAnthropicLLM("key", "claude-test", server.URL, false).Complete(context.Background(), LLMRequest{Prompt: "plan this"})
+	if err != nil || content != `{"tasks":[]}` {
+		t.Errorf("got content %q, err %v", content, err)
+	}
+
+	if _, err := NewAnthropicLLM("wrong", "claude-test", server.URL, false).Complete(context.Background(), LLMRequest{Prompt: "plan this"}); err == nil {
+		t.Errorf("expected an error for a rejected API key")
+	}
+}
+
+func TestLLMProvidersRequestStructuredOutput(t *testing.T) {
+	request := LLMRequest{Prompt: "plan this", SchemaName: callingPlanSchemaName, Schema: callingPlanSchema}
+
+	anthropicServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got anthropicRequest
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if len(got.Tools) != 1 || got.Tools[0].Name != callingPlanSchemaName || got.ToolChoice == nil || got.ToolChoice.Name != callingPlanSchemaName {
+			t.Errorf("expected the plan schema to be forced as a tool, got %+v", got)
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"Here you go"},{"type":"tool_use","name":"service_calling_plan","input":{"tasks":[]}}]}`))
+	}))
+	defer anthropicServer.Close()
+
+	content, err := NewAnthropicLLM("key", "claude-test", anthropicServer.URL, true).Complete(context.Background(), request)
+	if err != nil || content != `{"tasks":[]}` {
+		t.Errorf("got content %q, err %v", content, err)
+	}
+
+	openAIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var got struct {
+			ResponseFormat struct {
+				Type       string `json:"type"`
+				JSONSchema struct {
+					Name   string          `json:"name"`
+					Schema json.RawMessage `json:"schema"`
+				} `json:"json_schema"`
+			} `json:"response_format"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got.ResponseFormat.Type != "json_schema" || got.ResponseFormat.JSONSchema.Name != callingPlanSchemaName || len(got.ResponseFormat.JSONSchema.Schema) == 0 {
+			t.Errorf("expected a json_schema response format, got %+v", got.ResponseFormat)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"tasks\":[]}"}}]}`))
+	}))
+	defer openAIServer.Close()
+
+	clientConfig := openai.DefaultConfig("key")
+	clientConfig.BaseURL = openAIServer.URL
+	content, err = NewOpenAILLM(clientConfig, "gpt-test", true).Complete(context.Background(), request)
+	if err != nil || content != `{"tasks":[]}` {
+		t.Errorf("got content %q, err %v", content, err)
+	}
+}
+
+func TestPrepareOrchestrationPlansWithConfiguredProvider(t *testing.T) {
+	llm := NewFakeLLM("```json\n" + `{
+		"tasks": [
+			{"id": "task0", "input": {"message": "hi"}},
+			{"id": "task1", "service": "s1", "input": {"message": "$task0.message"}}
+		],
+		"parallel_groups": [["task1"]]
+	}` + "\n```")
+	plane, _ := newTestPlane(t, llm, echoService())
+
+	orchestration := echoOrchestration("o1", "hi")
+	plane.PrepareOrchestration(orchestration)
+
+	if !orchestration.Executable() {
+		t.Fatalf("expected an executable orchestration, got %s: %s", orchestration.Status.String(), orchestration.Error)
+	}
+	if len(orchestration.Plan.Tasks) != 1 || orchestration.Plan.Tasks[0].Service != "s1" {
+		t.Errorf("unexpected plan: %+v", orchestration.Plan)
+	}
+	if string(orchestration.taskZero) != `{"message":"hi"}` {
+		t.Errorf("unexpected task zero input: %s", orchestration.taskZero)
+	}
+	if prompts := llm.Prompts(); len(prompts) != 1 {
+		t.Errorf("expected a single planning prompt, got %d", len(prompts))
+	}
+}
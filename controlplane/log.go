@@ -3,16 +3,23 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"os"
+	"path/filepath"
 	"slices"
 	"time"
 )
 
-func NewLogManager(_ context.Context, retention time.Duration, controlPlane *ControlPlane) *LogManager {
+// NewLogManager creates a LogManager, when dir is set each orchestration's Log is persisted
+// to segment files under dir and retained there for the retention period after it is finalized.
+func NewLogManager(ctx context.Context, dir string, retention time.Duration, controlPlane *ControlPlane) *LogManager {
 	lm := &LogManager{
 		logs:           make(map[string]*Log),
 		orchestrations: make(map[string]*OrchestrationState),
+		dir:            dir,
 		retention:      retention,
 		cleanupTicker:  time.NewTicker(5 * time.Minute),
 		webhookClient: &http.Client{
@@ -21,7 +28,7 @@ func NewLogManager(_ context.Context, retention time.Duration, controlPlane *Con
 		controlPlane: controlPlane,
 	}
 
-	//go lm.startCleanup(ctx)
+	go lm.startCleanup(ctx)
 	return lm
 }
 
@@ -36,18 +43,59 @@ func (lm *LogManager) startCleanup(ctx context.Context) {
 	}
 }
 
+// cleanupStaleOrchestrations evicts finalized orchestrations once they outlive the retention period, then
+// prunes their persisted logs. The disk is only touched after the lock is released so readers are not stalled.
 func (lm *LogManager) cleanupStaleOrchestrations() {
-	lm.mu.Lock()
-	defer lm.mu.Unlock()
 	now := time.Now()
 
+	lm.mu.Lock()
 	for id, orchestrationState := range lm.orchestrations {
-		if orchestrationState.Status == Completed &&
-			now.Sub(orchestrationState.UpdatedAt) > lm.retention {
+		finalized := orchestrationState.Status == Completed || orchestrationState.Status == Failed
+		if finalized && now.Sub(orchestrationState.UpdatedAt) > lm.retention {
 			delete(lm.orchestrations, id)
 			delete(lm.logs, id)
 		}
 	}
+	active := make(map[string]struct{}, len(lm.logs))
+	for id := range lm.logs {
+		active[id] = struct{}{}
+	}
+	lm.mu.Unlock()
+
+	lm.pruneRetainedLogs(now, active)
+}
+
+// pruneRetainedLogs removes persisted logs of finalized orchestrations once they outlive the retention period,
+// logs of active orchestrations are kept.
+func (lm *LogManager) pruneRetainedLogs(now time.Time, active map[string]struct{}) {
+	if lm.dir == "" {
+		return
+	}
+
+	dirs, err := os.ReadDir(lm.dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			lm.Logger.Error().Err(err).Msg("Failed to list retained logs")
+		}
+		return
+	}
+
+	for _, dir := range dirs {
+		if _, isActive := active[dir.Name()]; isActive || !dir.IsDir() {
+			continue
+		}
+
+		modified, err := lastModified(filepath.Join(lm.dir, dir.Name()))
+		if err != nil || now.Sub(modified) <= lm.retention {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(lm.dir, dir.Name())); err != nil {
+			lm.Logger.Error().Err(err).Msgf("Failed to remove retained Log for orchestration: %s", dir.Name())
+			continue
+		}
+		lm.Logger.Debug().Msgf("Removed retained Log for orchestration: %s", dir.Name())
+	}
 }
 
 func (lm *LogManager) GetLog(orchestrationID string) *Log {
@@ -56,7 +104,44 @@ func (lm *LogManager) GetLog(orchestrationID string) *Log {
 	return lm.logs[orchestrationID]
 }
 
-func (lm *LogManager) CreateLog(orchestrationID string, plan *ServiceCallingPlan) *Log {
+// ReadRetainedLog loads a persisted Log, e.g. one belonging to an already finalized orchestration, for reading.
+func (lm *LogManager) ReadRetainedLog(orchestrationID string) (*Log, error) {
+	if log := lm.GetLog(orchestrationID); log != nil {
+		return log, nil
+	}
+
+	if lm.dir == "" {
+		return nil, fmt.Errorf("log for orchestration %s is not retained: %w", orchestrationID, ErrNotFound)
+	}
+
+	dir := filepath.Join(lm.dir, orchestrationID)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("log for orchestration %s: %w", orchestrationID, ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read retained log for orchestration %s: %w", orchestrationID, err)
+	}
+
+	segments, err := OpenSegmentLog(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func(segments *SegmentLog) {
+		_ = segments.Close()
+	}(segments)
+
+	entries, err := segments.ReadFrom(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retained log for orchestration %s: %w", orchestrationID, err)
+	}
+
+	return &Log{
+		Entries:       entries,
+		CurrentOffset: segments.NextOffset(),
+		lastAccessed:  time.Now(),
+	}, nil
+}
+
+func (lm *LogManager) CreateLog(orchestrationID string, plan *ServiceCallingPlan) (*Log, error) {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
 
@@ -65,8 +150,17 @@ func (lm *LogManager) CreateLog(orchestrationID string, plan *ServiceCallingPlan
 		lastAccessed: time.Now(),
 	}
 
+	if lm.dir != "" {
+		segments, err := OpenSegmentLog(filepath.Join(lm.dir, orchestrationID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create persistent log for orchestration %s: %w", orchestrationID, err)
+		}
+		log.segments = segments
+	}
+
 	state := &OrchestrationState{
 		ID:             orchestrationID,
+		ProjectID:      plan.ProjectID,
 		Plan:           plan,
 		CompletedTasks: make(map[string]bool),
 		Status:         Processing,
@@ -75,22 +169,103 @@ func (lm *LogManager) CreateLog(orchestrationID string, plan *ServiceCallingPlan
 
 	lm.logs[orchestrationID] = log
 	lm.orchestrations[orchestrationID] = state
+	lm.saveState(state)
 
 	lm.Logger.Debug().Msgf("Created Log for orchestration: %s", orchestrationID)
 
-	return log
+	return log, nil
 }
 
-func (lm *LogManager) MarkTaskCompleted(orchestrationID, taskID string) (Status, error) {
+// RecoverLog reopens the persisted Log of an orchestration that was still processing when the
+// control plane stopped, and restores its state using the tasks already completed in the Log.
+func (lm *LogManager) RecoverLog(orchestration *Orchestration) (*Log, error) {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
 
+	if lm.dir == "" {
+		return nil, fmt.Errorf("logs are not persisted, cannot recover orchestration %s", orchestration.ID)
+	}
+
+	segments, err := OpenSegmentLog(filepath.Join(lm.dir, orchestration.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen log for orchestration %s: %w", orchestration.ID, err)
+	}
+
+	entries, err := segments.ReadFrom(0)
+	if err != nil {
+		_ = segments.Close()
+		return nil, fmt.Errorf("failed to read log for orchestration %s: %w", orchestration.ID, err)
+	}
+
+	log := &Log{
+		Entries:       entries,
+		CurrentOffset: segments.NextOffset(),
+		lastAccessed:  time.Now(),
+		segments:      segments,
+	}
+
+	state, err := lm.controlPlane.store.GetOrchestrationState(orchestration.ID)
+	if err != nil {
+		state = &OrchestrationState{
+			ID:        orchestration.ID,
+			Status:    Processing,
+			CreatedAt: orchestration.Timestamp,
+		}
+	}
+	state.ProjectID = orchestration.ProjectID
+	state.Plan = orchestration.Plan
+	if state.CompletedTasks == nil {
+		state.CompletedTasks = make(map[string]bool)
+	}
+	for _, entry := range entries {
+		if entry.Type == "task_output" && entry.ID != TaskZero {
+			state.CompletedTasks[entry.ID] = true
+		}
+	}
+	state.UpdatedAt = time.Now()
+
+	lm.logs[orchestration.ID] = log
+	lm.orchestrations[orchestration.ID] = state
+	lm.saveState(state)
+
+	lm.Logger.Debug().
+		Int("Entries", len(entries)).
+		Msgf("Recovered Log for orchestration: %s", orchestration.ID)
+
+	return log, nil
+}
+
+// saveState persists the orchestration state, callers should hold the lm.mu lock.
+func (lm *LogManager) saveState(state *OrchestrationState) {
+	if err := lm.controlPlane.store.SaveOrchestrationState(state); err != nil {
+		lm.Logger.Error().Err(err).Msgf("Failed to save state for orchestration: %s", state.ID)
+	}
+}
+
+// activeState returns the state of an orchestration that has not been finalized yet, finalized orchestrations
+// keep their state until the retention period expires but no longer accept updates. Callers should hold the mu lock.
+func (lm *LogManager) activeState(orchestrationID string) (*OrchestrationState, error) {
 	state, ok := lm.orchestrations[orchestrationID]
 	if !ok {
-		return state.Status, fmt.Errorf("orchestration %s has no associated state", orchestrationID)
+		return nil, fmt.Errorf("orchestration %s has no associated state", orchestrationID)
+	}
+	if _, active := lm.logs[orchestrationID]; !active {
+		return nil, fmt.Errorf("orchestration %s is already finalized", orchestrationID)
+	}
+	return state, nil
+}
+
+func (lm *LogManager) MarkTaskCompleted(orchestrationID, taskID string) (Status, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	state, err := lm.activeState(orchestrationID)
+	if err != nil {
+		return 0, err
 	}
 	state.CompletedTasks[taskID] = true
 	state.UpdatedAt = time.Now()
+	lm.saveState(state)
 
 	return state.Status, nil
 }
@@ -99,13 +274,14 @@ func (lm *LogManager) MarkOrchestrationCompleted(orchestrationID string) (Status
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
 
-	state, ok := lm.orchestrations[orchestrationID]
-	if !ok {
-		return state.Status, fmt.Errorf("orchestration %s has no associated state", orchestrationID)
+	state, err := lm.activeState(orchestrationID)
+	if err != nil {
+		return 0, err
 	}
 
 	state.Status = Completed
 	state.UpdatedAt = time.Now()
+	lm.saveState(state)
 
 	return state.Status, nil
 }
@@ -114,14 +290,15 @@ func (lm *LogManager) MarkOrchestrationFailed(orchestrationID string, reason jso
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
 
-	state, ok := lm.orchestrations[orchestrationID]
-	if !ok {
-		return state.Status, fmt.Errorf("orchestration %s has no associated state", orchestrationID)
+	state, err := lm.activeState(orchestrationID)
+	if err != nil {
+		return 0, err
 	}
 
 	state.Error = string(reason)
 	state.Status = Failed
 	state.UpdatedAt = time.Now()
+	lm.saveState(state)
 
 	return state.Status, nil
 }
@@ -132,6 +309,18 @@ func (lm *LogManager) GetOrchestrationProjectID(orchestrationID string) string {
 	return lm.orchestrations[orchestrationID].ProjectID
 }
 
+// NotifyTaskEvent dispatches a task event to the webhooks of the orchestration's project.
+func (lm *LogManager) NotifyTaskEvent(eventType EventType, orchestrationID, taskID, serviceID string, value json.RawMessage) {
+	lm.mu.RLock()
+	state, err := lm.activeState(orchestrationID)
+	lm.mu.RUnlock()
+	if err != nil {
+		return
+	}
+
+	lm.controlPlane.NotifyEvent(state.ProjectID, newTaskEvent(eventType, orchestrationID, taskID, serviceID, value))
+}
+
 func (lm *LogManager) AppendFailureToLog(orchestrationID, id, producerID, reason string) error {
 	reasonData, err := json.Marshal(reason)
 	if err != nil {
@@ -162,9 +351,49 @@ func (lm *LogManager) FinalizeOrchestration(orchestrationID string, status Statu
 		return err
 	}
 
+	if log, ok := lm.logs[orchestrationID]; ok {
+		if err := log.Close(); err != nil {
+			lm.Logger.Error().Err(err).Msgf("Failed to close Log for orchestration: %s", orchestrationID)
+		}
+	}
+
+	delete(lm.logs, orchestrationID)
+
+	// The state is kept until cleanupStaleOrchestrations evicts it once the retention period expires
+	if state, ok := lm.orchestrations[orchestrationID]; ok {
+		state.Status = status
+		if reason != nil {
+			state.Error = string(reason)
+		}
+		state.UpdatedAt = time.Now()
+		lm.saveState(state)
+	}
+
+	return nil
+}
+
+// RemoveLog discards an orchestration's Log and state, including any persisted segment files.
+func (lm *LogManager) RemoveLog(orchestrationID string) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	if log, ok := lm.logs[orchestrationID]; ok {
+		if err := log.Close(); err != nil {
+			lm.Logger.Error().Err(err).Msgf("Failed to close Log for orchestration: %s", orchestrationID)
+		}
+	}
+
 	delete(lm.logs, orchestrationID)
 	delete(lm.orchestrations, orchestrationID)
 
+	if lm.dir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(filepath.Join(lm.dir, orchestrationID)); err != nil {
+		return fmt.Errorf("failed to remove log for orchestration %s: %w", orchestrationID, err)
+	}
+
+	lm.Logger.Debug().Msgf("Removed Log for orchestration: %s", orchestrationID)
 	return nil
 }
 
@@ -175,6 +404,12 @@ func (l *Log) Append(entry LogEntry) error {
 	entry.Offset = l.CurrentOffset
 	entry.Timestamp = time.Now()
 
+	if l.segments != nil {
+		if err := l.segments.Append(entry); err != nil {
+			return fmt.Errorf("failed to persist log entry: %w", err)
+		}
+	}
+
 	l.Entries = append(l.Entries, entry)
 	l.CurrentOffset += 1
 	l.lastAccessed = time.Now()
@@ -199,6 +434,46 @@ func (l *Log) GetCurrentOffset() uint64 {
 	return l.CurrentOffset
 }
 
+// Close releases the Log's segment files, if any, the persisted entries are kept.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.segments == nil {
+		return nil
+	}
+	err := l.segments.Close()
+	l.segments = nil
+	return err
+}
+
+func lastModified(dir string) (time.Time, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var out time.Time
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(out) {
+			out = info.ModTime()
+		}
+	}
+	return out, nil
+}
+
+func (s *LogState) clone() *LogState {
+	return &LogState{
+		LastOffset:      s.LastOffset,
+		Processed:       maps.Clone(s.Processed),
+		DependencyState: maps.Clone(s.DependencyState),
+	}
+}
+
 func (d DependencyState) SortedValues() []json.RawMessage {
 	var out []json.RawMessage
 	var keys []string
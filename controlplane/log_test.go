@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCleanupEvictsFinalizedOrchestrations(t *testing.T) {
+	plane, _ := newTestPlane(t, nil)
+	logsDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// A negative retention period makes every finalized orchestration stale
+	lm := NewLogManager(ctx, logsDir, -1, plane)
+	lm.Logger = zerolog.Nop()
+
+	for _, id := range []string{"o1", "o2", "o3"} {
+		if _, err := lm.CreateLog(id, &ServiceCallingPlan{ProjectID: "p1"}); err != nil {
+			t.Fatalf("failed to create log %s: %v", id, err)
+		}
+	}
+	_, _ = lm.MarkOrchestrationCompleted("o1")
+	_, _ = lm.MarkOrchestrationFailed("o2", json.RawMessage(`"boom"`))
+
+	lm.cleanupStaleOrchestrations()
+
+	for id, wantKept := range map[string]bool{"o1": false, "o2": false, "o3": true} {
+		_, statErr := os.Stat(filepath.Join(logsDir, id))
+		if kept := lm.GetLog(id) != nil; kept != wantKept {
+			t.Errorf("orchestration %s: got log kept %v, want %v", id, kept, wantKept)
+		}
+		if retained := statErr == nil; retained != wantKept {
+			t.Errorf("orchestration %s: got log retained on disk %v, want %v", id, retained, wantKept)
+		}
+	}
+}
+
+func TestCleanupKeepsFinalizedOrchestrationsUntilRetentionExpires(t *testing.T) {
+	plane, store := newTestPlane(t, nil)
+	logsDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lm := NewLogManager(ctx, logsDir, time.Hour, plane)
+	lm.Logger = zerolog.Nop()
+	plane.LogManager = lm
+
+	_ = store.SaveProject(&Project{ID: "p1"})
+	_ = store.SaveOrchestration(&Orchestration{ID: "o1", ProjectID: "p1", Status: Processing})
+	log, err := lm.CreateLog("o1", &ServiceCallingPlan{ProjectID: "p1"})
+	if err != nil {
+		t.Fatalf("failed to create log: %v", err)
+	}
+	_ = log.Append(LogEntry{Type: "task_output", ID: TaskZero, Value: json.RawMessage(`{"message":"hello"}`)})
+	if err := lm.FinalizeOrchestration("o1", Completed, nil, json.RawMessage(`{"echo":"hello"}`)); err != nil {
+		t.Fatalf("failed to finalize orchestration: %v", err)
+	}
+
+	if _, err := lm.MarkTaskCompleted("o1", "task1"); err == nil {
+		t.Errorf("expected a finalized orchestration to reject task updates")
+	}
+
+	lm.cleanupStaleOrchestrations()
+
+	if projectID := lm.GetOrchestrationProjectID("o1"); projectID != "p1" {
+		t.Errorf("expected the finalized orchestration to be kept within the retention period, got project %q", projectID)
+	}
+	if _, err := os.Stat(filepath.Join(logsDir, "o1")); err != nil {
+		t.Errorf("expected the log to be retained within the retention period: %v", err)
+	}
+
+	// Age the orchestration past the retention period
+	expired := time.Now().Add(-2 * time.Hour)
+	lm.mu.Lock()
+	lm.orchestrations["o1"].UpdatedAt = expired
+	lm.mu.Unlock()
+	segments, _ := os.ReadDir(filepath.Join(logsDir, "o1"))
+	for _, segment := range segments {
+		_ = os.Chtimes(filepath.Join(logsDir, "o1", segment.Name()), expired, expired)
+	}
+
+	lm.cleanupStaleOrchestrations()
+
+	lm.mu.RLock()
+	_, kept := lm.orchestrations["o1"]
+	lm.mu.RUnlock()
+	if kept {
+		t.Errorf("expected the finalized orchestration to be evicted after the retention period")
+	}
+	if _, err := os.Stat(filepath.Join(logsDir, "o1")); !os.IsNotExist(err) {
+		t.Errorf("expected the retained log to be removed after the retention period, got %v", err)
+	}
+}
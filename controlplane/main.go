@@ -21,19 +21,58 @@ func main() {
 		log.Fatalf("could not initialise control plane server: %s", err.Error())
 	}
 
-	plane := NewControlPlane(cfg.OpenApiKey)
+	store, err := NewStore(cfg)
+	if err != nil {
+		log.Fatalf("could not initialise control plane storage: %s", err.Error())
+	}
+	defer func(store Store) {
+		if err := store.Close(); err != nil {
+			app.Logger.Error().Err(err).Msg("Failed to close control plane storage")
+		}
+	}(store)
+
+	llm, err := NewLLMProvider(cfg)
+	if err != nil {
+		log.Fatalf("could not initialise LLM provider: %s", err.Error())
+	}
+
+	plane := NewControlPlane(llm, store)
 	plane.Logger = app.Logger
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	wsManager := NewWebSocketManager(app.Logger)
-	logManager := NewLogManager(ctx, LogsRetentionPeriod, plane)
+	logManager := NewLogManager(ctx, cfg.LogsDir(), LogsRetentionPeriod, plane)
 	logManager.Logger = app.Logger
 	plane.LogManager = logManager
 	plane.WebSocketManager = wsManager
 	plane.TidyWebSocketArtefacts(ctx)
 
+	if err := plane.MigrateProjects(); err != nil {
+		log.Fatalf("could not migrate projects: %s", err.Error())
+	}
+
+	if err := plane.LoadAPIKeys(); err != nil {
+		log.Fatalf("could not load API keys: %s", err.Error())
+	}
+
+	adminKey, err := plane.BootstrapAdmin(cfg.AdminKey)
+	if err != nil {
+		log.Fatalf("could not set up admin credential: %s", err.Error())
+	}
+	if adminKey != "" {
+		app.Logger.Warn().
+			Str("AdminKey", adminKey).
+			Msg("Generated the admin key, it will not be shown again. Keep it safe or configure ADMIN_KEY instead")
+	}
+
+	plane.StartWebhookDelivery(ctx)
+
+	if err := plane.RecoverOrchestrations(); err != nil {
+		app.Logger.Error().Err(err).Msg("Failed to recover in-flight orchestrations")
+	}
+
 	app.Plane = plane
 	app.Router = mux.NewRouter()
 	app.configureRoutes()
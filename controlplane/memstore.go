@@ -0,0 +1,451 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps everything in process memory, it is lost on restart and mainly useful for tests.
+type MemoryStore struct {
+	admin          *AdminCredential
+	projects       map[string]*Project
+	apiKeys        map[string]map[string]*APIKey
+	services       map[string]map[string]*ServiceInfo
+	versions       map[string]map[string][]*ServiceInfo
+	workflows      map[string]map[string]*Workflow
+	orchestrations map[string]*Orchestration
+	states         map[string]*OrchestrationState
+	workerStates   map[string]map[string]*LogState
+	deliveries     map[string]*WebhookDelivery
+	// pendingDeliveries indexes the deliveries that are not dead-lettered
+	pendingDeliveries map[string]struct{}
+	attempts          map[string][]*DeliveryAttempt
+	mu                sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		projects:          make(map[string]*Project),
+		apiKeys:           make(map[string]map[string]*APIKey),
+		services:          make(map[string]map[string]*ServiceInfo),
+		versions:          make(map[string]map[string][]*ServiceInfo),
+		workflows:         make(map[string]map[string]*Workflow),
+		orchestrations:    make(map[string]*Orchestration),
+		states:            make(map[string]*OrchestrationState),
+		workerStates:      make(map[string]map[string]*LogState),
+		deliveries:        make(map[string]*WebhookDelivery),
+		pendingDeliveries: make(map[string]struct{}),
+		attempts:          make(map[string][]*DeliveryAttempt),
+	}
+}
+
+func (s *MemoryStore) SaveProject(project *Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.projects[project.ID] = project.clone()
+	return nil
+}
+
+func (s *MemoryStore) GetProject(id string) (*Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	project, exists := s.projects[id]
+	if !exists {
+		return nil, fmt.Errorf("project %s: %w", id, ErrNotFound)
+	}
+	return project.clone(), nil
+}
+
+func (s *MemoryStore) ListProjects() ([]*Project, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*Project, 0, len(s.projects))
+	for _, project := range s.projects {
+		out = append(out, project.clone())
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteProject(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.projects[id]; !exists {
+		return fmt.Errorf("project %s: %w", id, ErrNotFound)
+	}
+
+	for orchestrationID, orchestration := range s.orchestrations {
+		if orchestration.ProjectID != id {
+			continue
+		}
+		delete(s.orchestrations, orchestrationID)
+		delete(s.states, orchestrationID)
+		delete(s.workerStates, orchestrationID)
+		delete(s.attempts, orchestrationID)
+	}
+	for deliveryID, delivery := range s.deliveries {
+		if delivery.ProjectID == id {
+			delete(s.deliveries, deliveryID)
+			delete(s.pendingDeliveries, deliveryID)
+		}
+	}
+	delete(s.services, id)
+	delete(s.versions, id)
+	delete(s.workflows, id)
+	delete(s.apiKeys, id)
+	delete(s.projects, id)
+	return nil
+}
+
+func (s *MemoryStore) SaveAPIKey(key *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectKeys, exists := s.apiKeys[key.ProjectID]
+	if !exists {
+		projectKeys = make(map[string]*APIKey)
+		s.apiKeys[key.ProjectID] = projectKeys
+	}
+	projectKeys[key.ID] = key.clone()
+	return nil
+}
+
+func (s *MemoryStore) ListAPIKeys(projectID string) ([]*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*APIKey
+	for _, key := range s.apiKeys[projectID] {
+		out = append(out, key.clone())
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) SaveAdminCredential(credential *AdminCredential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *credential
+	s.admin = &stored
+	return nil
+}
+
+func (s *MemoryStore) GetAdminCredential() (*AdminCredential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.admin == nil {
+		return nil, fmt.Errorf("admin credential: %w", ErrNotFound)
+	}
+	out := *s.admin
+	return &out, nil
+}
+
+func (s *MemoryStore) SaveService(service *ServiceInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectServices, exists := s.services[service.ProjectID]
+	if !exists {
+		projectServices = make(map[string]*ServiceInfo)
+		s.services[service.ProjectID] = projectServices
+	}
+	stored := *service
+	projectServices[service.ID] = &stored
+
+	projectVersions, exists := s.versions[service.ProjectID]
+	if !exists {
+		projectVersions = make(map[string][]*ServiceInfo)
+		s.versions[service.ProjectID] = projectVersions
+	}
+	versions := slices.DeleteFunc(projectVersions[service.ID], func(version *ServiceInfo) bool {
+		return version.Version == service.Version
+	})
+	versions = append(versions, &stored)
+	slices.SortFunc(versions, func(a, b *ServiceInfo) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	projectVersions[service.ID] = versions
+	return nil
+}
+
+func (s *MemoryStore) GetService(projectID, serviceID string) (*ServiceInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	service, exists := s.services[projectID][serviceID]
+	if !exists {
+		return nil, fmt.Errorf("service %s for project %s: %w", serviceID, projectID, ErrNotFound)
+	}
+	out := *service
+	return &out, nil
+}
+
+func (s *MemoryStore) ListServices(projectID string) ([]*ServiceInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*ServiceInfo
+	for _, service := range s.services[projectID] {
+		svc := *service
+		out = append(out, &svc)
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) GetServiceVersion(projectID, serviceID string, version int64) (*ServiceInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, service := range s.versions[projectID][serviceID] {
+		if service.Version == version {
+			out := *service
+			return &out, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s version %d for project %s: %w", serviceID, version, projectID, ErrNotFound)
+}
+
+func (s *MemoryStore) ListServiceVersions(projectID, serviceID string) ([]*ServiceInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*ServiceInfo
+	for _, service := range s.versions[projectID][serviceID] {
+		svc := *service
+		out = append(out, &svc)
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) SaveWorkflow(workflow *Workflow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projectWorkflows, exists := s.workflows[workflow.ProjectID]
+	if !exists {
+		projectWorkflows = make(map[string]*Workflow)
+		s.workflows[workflow.ProjectID] = projectWorkflows
+	}
+	projectWorkflows[workflow.Name] = workflow.clone()
+	return nil
+}
+
+func (s *MemoryStore) GetWorkflow(projectID, name string) (*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	workflow, exists := s.workflows[projectID][name]
+	if !exists {
+		return nil, fmt.Errorf("workflow %s for project %s: %w", name, projectID, ErrNotFound)
+	}
+	return workflow.clone(), nil
+}
+
+func (s *MemoryStore) ListWorkflows(projectID string) ([]*Workflow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*Workflow
+	for _, workflow := range s.workflows[projectID] {
+		out = append(out, workflow.clone())
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteWorkflow(projectID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.workflows[projectID][name]; !exists {
+		return fmt.Errorf("workflow %s for project %s: %w", name, projectID, ErrNotFound)
+	}
+	delete(s.workflows[projectID], name)
+	return nil
+}
+
+func (s *MemoryStore) SaveOrchestration(orchestration *Orchestration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *orchestration
+	s.orchestrations[orchestration.ID] = &stored
+	return nil
+}
+
+func (s *MemoryStore) GetOrchestration(id string) (*Orchestration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orchestration, exists := s.orchestrations[id]
+	if !exists {
+		return nil, fmt.Errorf("orchestration %s: %w", id, ErrNotFound)
+	}
+	out := *orchestration
+	return &out, nil
+}
+
+func (s *MemoryStore) ListOrchestrations(projectID string) ([]*Orchestration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*Orchestration
+	for _, orchestration := range s.orchestrations {
+		if orchestration.ProjectID != projectID {
+			continue
+		}
+		o := *orchestration
+		out = append(out, &o)
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) SaveOrchestrationState(state *OrchestrationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *state
+	stored.CompletedTasks = maps.Clone(state.CompletedTasks)
+	s.states[state.ID] = &stored
+	return nil
+}
+
+func (s *MemoryStore) GetOrchestrationState(id string) (*OrchestrationState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, exists := s.states[id]
+	if !exists {
+		return nil, fmt.Errorf("orchestration state %s: %w", id, ErrNotFound)
+	}
+	out := *state
+	out.CompletedTasks = maps.Clone(state.CompletedTasks)
+	return &out, nil
+}
+
+func (s *MemoryStore) SaveWorkerState(orchestrationID, workerID string, state *LogState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workers, exists := s.workerStates[orchestrationID]
+	if !exists {
+		workers = make(map[string]*LogState)
+		s.workerStates[orchestrationID] = workers
+	}
+	workers[workerID] = state.clone()
+	return nil
+}
+
+func (s *MemoryStore) GetWorkerState(orchestrationID, workerID string) (*LogState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, exists := s.workerStates[orchestrationID][workerID]
+	if !exists {
+		return nil, fmt.Errorf("worker %s state for orchestration %s: %w", workerID, orchestrationID, ErrNotFound)
+	}
+	return state.clone(), nil
+}
+
+func (s *MemoryStore) SaveWebhookDelivery(delivery *WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[delivery.ID] = delivery.clone()
+	if delivery.Dead() {
+		delete(s.pendingDeliveries, delivery.ID)
+	} else {
+		s.pendingDeliveries[delivery.ID] = struct{}{}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetWebhookDelivery(id string) (*WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	delivery, exists := s.deliveries[id]
+	if !exists {
+		return nil, fmt.Errorf("webhook delivery %s: %w", id, ErrNotFound)
+	}
+	return delivery.clone(), nil
+}
+
+func (s *MemoryStore) ListWebhookDeliveries(projectID string) ([]*WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if projectID == "" || delivery.ProjectID == projectID {
+			out = append(out, delivery.clone())
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) ListDueWebhookDeliveries(now time.Time) ([]*WebhookDelivery, time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var due []*WebhookDelivery
+	var next time.Time
+	for id := range s.pendingDeliveries {
+		delivery := s.deliveries[id]
+		if delivery.NextAttemptAt.After(now) {
+			if next.IsZero() || delivery.NextAttemptAt.Before(next) {
+				next = delivery.NextAttemptAt
+			}
+			continue
+		}
+		due = append(due, delivery.clone())
+	}
+	slices.SortFunc(due, func(a, b *WebhookDelivery) int {
+		return a.NextAttemptAt.Compare(b.NextAttemptAt)
+	})
+	return due, next, nil
+}
+
+func (s *MemoryStore) DeleteWebhookDelivery(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.deliveries[id]; !exists {
+		return fmt.Errorf("webhook delivery %s: %w", id, ErrNotFound)
+	}
+	delete(s.deliveries, id)
+	delete(s.pendingDeliveries, id)
+	return nil
+}
+
+func (s *MemoryStore) SaveDeliveryAttempt(attempt *DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *attempt
+	s.attempts[attempt.OrchestrationID] = append(s.attempts[attempt.OrchestrationID], &stored)
+	return nil
+}
+
+func (s *MemoryStore) ListDeliveryAttempts(orchestrationID string) ([]*DeliveryAttempt, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*DeliveryAttempt, 0, len(s.attempts[orchestrationID]))
+	for _, attempt := range s.attempts[orchestrationID] {
+		stored := *attempt
+		out = append(out, &stored)
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
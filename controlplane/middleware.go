@@ -2,32 +2,101 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gilcrest/diygoapi/errs"
+	"github.com/gorilla/mux"
 )
 
-func (app *App) APIKeyMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// APIKeyMiddleware authenticates the request's API key and checks it grants the scope,
+// the key's project and the key itself are stored in the request context.
+func (app *App) APIKeyMiddleware(scope Scope, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, "Authorization header is missing"))
+		key, err := bearerToken(r)
+		if err != nil {
+			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, err))
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, "Invalid Authorization header format"))
+		project, apiKey, err := app.Plane.AuthenticateAPIKey(key)
+		if err != nil {
+			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, err))
 			return
 		}
 
-		apiKey := parts[1]
+		if !apiKey.Allows(scope) {
+			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, fmt.Sprintf("API key is missing the %s scope", scope)))
+			return
+		}
 
-		// Store the API key in the request context
-		ctx := context.WithValue(r.Context(), "api_key", apiKey)
+		// Store the project and API key in the request context
+		ctx := context.WithValue(r.Context(), "role", RoleProject)
+		ctx = context.WithValue(ctx, "project", project)
+		ctx = context.WithValue(ctx, "api_key", apiKey)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
 	}
 }
+
+// AdminMiddleware only lets requests authenticated with the admin credential through.
+func (app *App) AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := bearerToken(r)
+		if err != nil {
+			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, err))
+			return
+		}
+
+		if !app.Plane.IsAdminKey(key) {
+			errs.HTTPErrorResponse(w, app.Logger, errs.E(errs.Unauthorized, "admin credential required"))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "role", RoleAdmin)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// AdminOrAPIKeyMiddleware accepts either the admin credential or an API key granting the scope.
+// Admin requests addressing a project by its id have that project stored in the request context.
+func (app *App) AdminOrAPIKeyMiddleware(scope Scope, next http.HandlerFunc) http.HandlerFunc {
+	withAPIKey := app.APIKeyMiddleware(scope, next)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := bearerToken(r)
+		if err != nil || !app.Plane.IsAdminKey(key) {
+			withAPIKey(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "role", RoleAdmin)
+		if projectID, ok := mux.Vars(r)["id"]; ok {
+			project, err := app.Plane.GetProject(projectID)
+			if err != nil {
+				errs.HTTPErrorResponse(w, app.Logger, storeErrorKind(err))
+				return
+			}
+			ctx = context.WithValue(ctx, "project", project)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", errors.New("Authorization header is missing")
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", errors.New("Invalid Authorization header format")
+	}
+
+	return parts[1], nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	PlanCacheHit  = "hit"
+	PlanCacheMiss = "miss"
+)
+
+// planCache keeps the plans prepared for actions so repeated actions skip the LLM. Plans are keyed on
+// the project, the normalised action, the action's param field names and the versions of the
+// project's services, so they are replanned as soon as a service changes.
+type planCache struct {
+	entries map[string]*planCacheEntry
+	mu      sync.Mutex
+}
+
+type planCacheEntry struct {
+	plan []byte
+	// taskZeroFields are the param fields task0 passes on to the plan's tasks
+	taskZeroFields []string
+	expiresAt      time.Time
+}
+
+func newPlanCache() *planCache {
+	return &planCache{entries: make(map[string]*planCacheEntry)}
+}
+
+func planCacheKey(orchestration *Orchestration, services []*ServiceInfo) string {
+	fields := make([]string, 0, len(orchestration.Params))
+	for _, param := range orchestration.Params {
+		fields = append(fields, param.Field)
+	}
+	sort.Strings(fields)
+
+	versions := make([]string, 0, len(services))
+	for _, service := range services {
+		versions = append(versions, fmt.Sprintf("%s@%d", service.ID, service.Version))
+	}
+	sort.Strings(versions)
+
+	hash := sha256.New()
+	for _, part := range []string{
+		orchestration.ProjectID,
+		normaliseAction(orchestration.Action.Type),
+		normaliseAction(orchestration.Action.Content),
+		strings.Join(fields, ","),
+		strings.Join(versions, ","),
+	} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func normaliseAction(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// get returns a copy of the cached plan, and task0's input built from the orchestration's params.
+func (c *planCache) get(key string, orchestration *Orchestration) (*ServiceCallingPlan, json.RawMessage, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, nil, false
+	}
+
+	var plan *ServiceCallingPlan
+	if err := json.Unmarshal(entry.plan, &plan); err != nil {
+		return nil, nil, false
+	}
+	plan.ProjectID = orchestration.ProjectID
+
+	params := make(map[string]string, len(orchestration.Params))
+	for _, param := range orchestration.Params {
+		params[param.Field] = param.Value
+	}
+	input := make(map[string]Source, len(entry.taskZeroFields))
+	for _, field := range entry.taskZeroFields {
+		input[field] = Source(params[field])
+	}
+	taskZeroInput, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return plan, taskZeroInput, true
+}
+
+// put caches a validated plan. Plans are only cached when task0 passes the action's params on as they are,
+// otherwise task0's values can't be refreshed from another orchestration's params.
+func (c *planCache) put(key string, orchestration *Orchestration, taskZero *SubTask, plan *ServiceCallingPlan) {
+	if PlanCacheTTL <= 0 {
+		return
+	}
+
+	params := make(map[string]string, len(orchestration.Params))
+	for _, param := range orchestration.Params {
+		params[param.Field] = param.Value
+	}
+	for field, value := range taskZero.Input {
+		if param, ok := params[field]; !ok || param != string(value) {
+			return
+		}
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= PlanCacheMaxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = &planCacheEntry{
+		plan:           data,
+		taskZeroFields: sortedKeys(taskZero.Input),
+		expiresAt:      now.Add(PlanCacheTTL),
+	}
+}
+
+// evict drops expired entries, or the entry expiring first when none have expired. Callers must hold mu.
+func (c *planCache) evict(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+	if len(c.entries) >= PlanCacheMaxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
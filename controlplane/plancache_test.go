@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPrepareOrchestrationReusesCachedPlans(t *testing.T) {
+	service := echoService()
+	llm := NewFakeLLM(echoPlan)
+	plane, store := newTestPlane(t, llm, service)
+
+	prepare := func(id, action, message string) *Orchestration {
+		orchestration := echoOrchestration(id, message)
+		orchestration.Action.Content = action
+		plane.PrepareOrchestration(orchestration)
+		if !orchestration.Executable() {
+			t.Fatalf("expected an executable orchestration, got %s: %s", orchestration.Status.String(), orchestration.Error)
+		}
+		return orchestration
+	}
+
+	first := prepare("o1", "Echo this", "hi")
+	if first.PlanCache != PlanCacheMiss {
+		t.Errorf("expected a cache miss, got %q", first.PlanCache)
+	}
+
+	second := prepare("o2", "  echo   THIS ", "hello")
+	if second.PlanCache != PlanCacheHit || len(llm.Prompts()) != 1 {
+		t.Errorf("expected a cache hit without planning, got %q after %d prompts", second.PlanCache, len(llm.Prompts()))
+	}
+	if string(second.taskZero) != `{"message":"hello"}` {
+		t.Errorf("expected task zero to use the new params, got %s", second.taskZero)
+	}
+	if second.Plan.ProjectID != "p1" || second.Plan.Tasks[0].Service != "s1" || second.Plan.DAG == nil {
+		t.Errorf("unexpected cached plan: %+v", second.Plan)
+	}
+
+	service.Version = 2
+	_ = store.SaveService(service)
+	if third := prepare("o3", "Echo this", "hey"); third.PlanCache != PlanCacheMiss || len(llm.Prompts()) != 2 {
+		t.Errorf("expected a new service version to miss the cache, got %q after %d prompts", third.PlanCache, len(llm.Prompts()))
+	}
+}
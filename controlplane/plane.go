@@ -1,26 +1,24 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/sashabaranov/go-openai"
 )
 
-func NewControlPlane(openAIKey string) *ControlPlane {
+func NewControlPlane(llm LLMProvider, store Store) *ControlPlane {
 	plane := &ControlPlane{
-		projects:           make(map[string]*Project),
-		services:           make(map[string]map[string]*ServiceInfo),
-		orchestrationStore: make(map[string]*Orchestration),
-		logWorkers:         make(map[string]map[string]context.CancelFunc),
-		openAIKey:          openAIKey,
+		store:      store,
+		apiKeys:    make(map[string]*APIKey),
+		logWorkers: make(map[string]map[string]context.CancelFunc),
+		deliverer:  newWebhookDeliverer(),
+		llm:        llm,
+		planCache:  newPlanCache(),
 	}
 	return plane
 }
@@ -40,20 +38,46 @@ func (p *ControlPlane) TidyWebSocketArtefacts(ctx context.Context) {
 	}()
 }
 
-func (p *ControlPlane) RegisterOrUpdateService(service *ServiceInfo) error {
-	p.servicesMu.Lock()
-	defer p.servicesMu.Unlock()
+// RegisterProject saves the project, adding its Webhook if any, and sets its APIKey to a newly
+// created default key with every scope.
+func (p *ControlPlane) RegisterProject(project *Project) error {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return err
+	}
 
-	projectServices, exists := p.services[service.ProjectID]
-	if !exists {
-		p.Logger.Debug().
-			Str("ProjectID", service.ProjectID).
-			Str("ServiceName", service.Name).
-			Msgf("Creating new project service")
-		projectServices = make(map[string]*ServiceInfo)
-		p.services[service.ProjectID] = projectServices
+	p.projectsMu.Lock()
+	project.APIKey = ""
+	project.WebhookSecret = secret
+	if project.Webhook != "" {
+		project.Webhooks = append(project.Webhooks, newWebhook(project.Webhook, DefaultWebhookEvents))
+		project.Webhook = ""
+	}
+	err = p.store.SaveProject(project)
+	p.projectsMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to save project %s: %w", project.ID, err)
+	}
+
+	key, err := p.CreateAPIKey(project.ID, DefaultAPIKeyName, AllScopes)
+	if err != nil {
+		return err
+	}
+	project.APIKey = key.Key
+	return nil
+}
+
+// RegisterOrUpdateService registers a new service or a new version of an existing one, returning how an
+// update changes the service's schema. With rejectBreaking an update with breaking changes is not saved.
+func (p *ControlPlane) RegisterOrUpdateService(service *ServiceInfo, rejectBreaking bool) ([]SchemaChange, error) {
+	if err := validateServiceSchema(service.Schema); err != nil {
+		return nil, err
 	}
 
+	p.servicesMu.Lock()
+	defer p.servicesMu.Unlock()
+
+	var changes []SchemaChange
 	if len(strings.TrimSpace(service.ID)) == 0 {
 		service.ID = p.generateServiceKey(service.ProjectID)
 		service.Version = 1
@@ -63,10 +87,22 @@ func (p *ControlPlane) RegisterOrUpdateService(service *ServiceInfo) error {
 			Str("ServiceName", service.Name).
 			Msgf("Generating new service ID")
 	} else {
-		existingService, exists := projectServices[service.ID]
-		if !exists {
-			return fmt.Errorf("service with key %s not found in project %s", service.ID, service.ProjectID)
+		existingService, err := p.store.GetService(service.ProjectID, service.ID)
+		if err != nil {
+			return nil, fmt.Errorf("service with key %s not found in project %s", service.ID, service.ProjectID)
+		}
+
+		changes = compareServiceSchemas(existingService.Schema, service.Schema)
+		if breaking := breakingChanges(changes); len(breaking) > 0 {
+			if rejectBreaking {
+				return changes, fmt.Errorf("%w to service %s: %s", ErrBreakingSchemaChange, service.ID, joinChanges(breaking))
+			}
+			p.Logger.Warn().
+				Str("ProjectID", service.ProjectID).
+				Str("ServiceID", service.ID).
+				Msgf("Service update has breaking schema changes: %s", joinChanges(breaking))
 		}
+
 		service.ID = existingService.ID
 		service.Version = existingService.Version + 1
 
@@ -77,28 +113,89 @@ func (p *ControlPlane) RegisterOrUpdateService(service *ServiceInfo) error {
 			Int64("ServiceVersion", service.Version).
 			Msgf("Updating existing service")
 	}
-	projectServices[service.ID] = service
 
-	return nil
+	if err := p.store.SaveService(service); err != nil {
+		return nil, fmt.Errorf("failed to save service %s: %w", service.ID, err)
+	}
+
+	return changes, nil
 }
 
 func (p *ControlPlane) GetServiceName(projectID string, serviceID string) (string, error) {
 	p.servicesMu.RLock()
 	defer p.servicesMu.RUnlock()
 
-	projectServices := p.services[projectID]
-	svc, exists := projectServices[serviceID]
-	if !exists {
+	svc, err := p.store.GetService(projectID, serviceID)
+	if err != nil {
 		return "", fmt.Errorf("service %s not found for project %s", serviceID, projectID)
 	}
 	return svc.Name, nil
 }
 
+// GetServiceVersion returns a version of the service, or its latest version when the version is 0.
+func (p *ControlPlane) GetServiceVersion(projectID string, serviceID string, version int64) (*ServiceInfo, error) {
+	p.servicesMu.RLock()
+	defer p.servicesMu.RUnlock()
+
+	svc, err := p.store.GetService(projectID, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found for project %s", serviceID, projectID)
+	}
+	if version == 0 || version == svc.Version {
+		return svc, nil
+	}
+
+	if svc, err = p.store.GetServiceVersion(projectID, serviceID, version); err != nil {
+		return nil, fmt.Errorf("service %s has no version %d in project %s", serviceID, version, projectID)
+	}
+	return svc, nil
+}
+
+// ServiceVersions returns the versions of the service, oldest first. Services last registered before
+// versions were kept only have their latest version.
+func (p *ControlPlane) ServiceVersions(projectID string, serviceID string) ([]*ServiceInfo, error) {
+	p.servicesMu.RLock()
+	defer p.servicesMu.RUnlock()
+
+	svc, err := p.store.GetService(projectID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := p.store.ListServiceVersions(projectID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 || versions[len(versions)-1].Version != svc.Version {
+		versions = append(versions, svc)
+	}
+	return versions, nil
+}
+
+// GetServiceSchema returns the schema of the service's task inputs and outputs for a version of the service,
+// or its latest version when the version is 0.
+func (p *ControlPlane) GetServiceSchema(projectID string, serviceID string, version int64) (ServiceSchema, error) {
+	svc, err := p.GetServiceVersion(projectID, serviceID, version)
+	if err != nil {
+		return ServiceSchema{}, err
+	}
+	return svc.Schema, nil
+}
+
+// PrepareOrchestration plans the orchestration and saves it. Planning can take several LLM calls so the
+// orchestration store is only locked to save the planned orchestration.
 func (p *ControlPlane) PrepareOrchestration(orchestration *Orchestration) {
+	p.PlanOrchestration(orchestration)
+
 	p.orchestrationStoreMu.Lock()
 	defer p.orchestrationStoreMu.Unlock()
+	p.saveOrchestration(orchestration)
+}
 
-	p.orchestrationStore[orchestration.ID] = orchestration
+// PlanOrchestration discovers the project's services, plans the orchestration's action and validates the
+// plan. Unlike PrepareOrchestration the orchestration isn't saved, so a plan can be reviewed without it
+// ever executing.
+func (p *ControlPlane) PlanOrchestration(orchestration *Orchestration) {
 	services, err := p.discoverProjectServices(orchestration.ProjectID)
 	if err != nil {
 		p.Logger.Error().
@@ -111,6 +208,20 @@ func (p *ControlPlane) PrepareOrchestration(orchestration *Orchestration) {
 		return
 	}
 
+	if orchestration.Plan != nil || orchestration.Workflow != "" {
+		p.planStaticOrchestration(orchestration, services)
+		return
+	}
+
+	cacheKey := planCacheKey(orchestration, services)
+	if plan, taskZeroInput, ok := p.planCache.get(cacheKey, orchestration); ok {
+		orchestration.PlanCache = PlanCacheHit
+		orchestration.Plan = plan
+		orchestration.taskZero = taskZeroInput
+		return
+	}
+	orchestration.PlanCache = PlanCacheMiss
+
 	callingPlan, err := p.decomposeAction(orchestration, services)
 	if err != nil {
 		p.Logger.Error().
@@ -123,59 +234,109 @@ func (p *ControlPlane) PrepareOrchestration(orchestration *Orchestration) {
 		return
 	}
 
-	if p.cannotExecuteAction(callingPlan.Tasks) {
-		orchestration.Plan = callingPlan
-		orchestration.Status = NotActionable
-		marshaledErr, _ := json.Marshal(callingPlan.Tasks[0].Input["error"])
-		orchestration.Error = marshaledErr
-		return
+	for attempt := 1; ; attempt++ {
+		if p.cannotExecuteAction(callingPlan.Tasks) {
+			orchestration.recordPlanningAttempt(attempt, callingPlan, nil)
+			orchestration.Plan = callingPlan
+			orchestration.Status = NotActionable
+			marshaledErr, _ := json.Marshal(callingPlan.Tasks[0].Input["error"])
+			orchestration.Error = marshaledErr
+			return
+		}
+
+		taskZeroInput, onlyServicesCallingPlan, err := p.checkCallingPlan(services, callingPlan)
+		orchestration.recordPlanningAttempt(attempt, callingPlan, err)
+		if err == nil {
+			orchestration.Plan = onlyServicesCallingPlan
+			orchestration.taskZero = taskZeroInput
+			taskZero, _ := p.callingPlanMinusTaskZero(callingPlan)
+			p.planCache.put(cacheKey, orchestration, taskZero, onlyServicesCallingPlan)
+			return
+		}
+
+		p.Logger.Info().
+			Str("OrchestrationID", orchestration.ID).
+			Int("Attempt", attempt).
+			Err(err).
+			Msg("Calling plan rejected")
+
+		if attempt > MaxPlanRepairAttempts {
+			orchestration.Plan = callingPlan
+			orchestration.Status = Failed
+			marshaledErr, _ := json.Marshal(err.Error())
+			orchestration.Error = marshaledErr
+			return
+		}
+
+		callingPlan, err = p.repairCallingPlan(orchestration, services, callingPlan, err)
+		if err != nil {
+			p.Logger.Error().
+				Str("OrchestrationID", orchestration.ID).
+				Err(fmt.Errorf("error repairing calling plan: %w", err))
+
+			orchestration.Status = Failed
+			marshaledErr, _ := json.Marshal(fmt.Sprintf("Error repairing calling plan: %s", err.Error()))
+			orchestration.Error = marshaledErr
+			return
+		}
 	}
+}
 
+// checkCallingPlan validates a calling plan against the project's services, it returns task zero's input
+// and the plan's service tasks, completed with their service details, when the plan can be executed.
+func (p *ControlPlane) checkCallingPlan(services []*ServiceInfo, callingPlan *ServiceCallingPlan) (json.RawMessage, *ServiceCallingPlan, error) {
 	taskZero, onlyServicesCallingPlan := p.callingPlanMinusTaskZero(callingPlan)
 	if taskZero == nil {
-		p.Logger.Error().
-			Str("OrchestrationID", orchestration.ID).
-			Err(fmt.Errorf("error locating task zero in calling plan"))
-
-		orchestration.Plan = callingPlan
-		orchestration.Status = Failed
-		marshaledErr, _ := json.Marshal(fmt.Sprintf("Error locating task zero in calling plan"))
-		orchestration.Error = marshaledErr
-		return
+		return nil, nil, fmt.Errorf("Error locating task zero in calling plan")
 	}
 
 	taskZeroInput, err := json.Marshal(taskZero.Input)
 	if err != nil {
-		orchestration.Status = Failed
-		marshaledErr, _ := json.Marshal(fmt.Sprintf("Failed to convert task zero into valid params: %v", err))
-		orchestration.Error = marshaledErr
-		return
+		return nil, nil, fmt.Errorf("Failed to convert task zero into valid params: %v", err)
+	}
+
+	if services, err = p.pinServiceVersions(callingPlan.ProjectID, services, onlyServicesCallingPlan.Tasks); err != nil {
+		return nil, nil, fmt.Errorf("Error pinning service versions: %s", err.Error())
 	}
 
 	if err = p.validateInput(services, onlyServicesCallingPlan.Tasks); err != nil {
-		orchestration.Status = Failed
-		marshaledErr, _ := json.Marshal(fmt.Sprintf("Error validating plan input/output: %s", err.Error()))
-		orchestration.Error = marshaledErr
-		return
+		return nil, nil, fmt.Errorf("Error validating plan input/output: %s", err.Error())
+	}
+
+	if err = validateCallingPlan(services, taskZero, onlyServicesCallingPlan); err != nil {
+		return nil, nil, fmt.Errorf("Error validating calling plan: %s", err.Error())
+	}
+
+	if err = onlyServicesCallingPlan.scheduleExecution(); err != nil {
+		return nil, nil, fmt.Errorf("Error scheduling calling plan: %s", err.Error())
 	}
 
 	if err := p.addServiceDetails(services, onlyServicesCallingPlan.Tasks); err != nil {
-		orchestration.Status = Failed
-		marshaledErr, _ := json.Marshal(fmt.Sprintf("Error adding service details to calling plan: %s", err.Error()))
-		orchestration.Error = marshaledErr
-		return
+		return nil, nil, fmt.Errorf("Error adding service details to calling plan: %s", err.Error())
 	}
 
-	orchestration.Plan = onlyServicesCallingPlan
-	orchestration.taskZero = taskZeroInput
+	return taskZeroInput, onlyServicesCallingPlan, nil
 }
 
 func (p *ControlPlane) ExecuteOrchestration(orchestration *Orchestration) {
+	p.orchestrationStoreMu.Lock()
+	processing := *orchestration
+	processing.Status = Processing
+	p.saveOrchestration(&processing)
+	p.orchestrationStoreMu.Unlock()
+
 	p.Logger.Debug().Msgf("About to create Log for orchestration %s", orchestration.ID)
-	log := p.LogManager.CreateLog(orchestration.ID, orchestration.Plan)
+	log, err := p.LogManager.CreateLog(orchestration.ID, orchestration.Plan)
+	if err != nil {
+		p.failExecution(orchestration.ID, fmt.Errorf("error creating Log: %w", err))
+		return
+	}
 
 	p.Logger.Debug().Msgf("About to create and start workers for orchestration %s", orchestration.ID)
-	p.createAndStartWorkers(orchestration.ID, orchestration.Plan)
+	if err := p.createAndStartWorkers(orchestration.ID, orchestration.Plan); err != nil {
+		p.failExecution(orchestration.ID, err)
+		return
+	}
 
 	initialEntry := LogEntry{
 		Type:       "task_output",
@@ -186,13 +347,27 @@ func (p *ControlPlane) ExecuteOrchestration(orchestration *Orchestration) {
 
 	p.Logger.Debug().Msgf("About to append initial entry to Log for orchestration %s", orchestration.ID)
 	if err := log.Append(initialEntry); err != nil {
-		p.Logger.Error().
-			Str("OrchestrationID", orchestration.ID).
-			Err(fmt.Errorf("error appending initial entry: %w", err))
+		p.failExecution(orchestration.ID, fmt.Errorf("error appending initial entry: %w", err))
 		return
 	}
 }
 
+// failExecution fails an orchestration that could not be started, it would otherwise stay processing forever.
+func (p *ControlPlane) failExecution(orchestrationID string, reason error) {
+	p.Logger.Error().
+		Str("OrchestrationID", orchestrationID).
+		Err(reason).
+		Msg("Failed to execute orchestration")
+
+	marshaledErr, _ := json.Marshal(reason.Error())
+	if err := p.LogManager.FinalizeOrchestration(orchestrationID, Failed, marshaledErr, nil); err != nil {
+		p.Logger.Error().
+			Str("OrchestrationID", orchestrationID).
+			Err(err).
+			Msg("Failed to finalize orchestration that could not be executed")
+	}
+}
+
 func (p *ControlPlane) FinalizeOrchestration(
 	orchestrationID string,
 	status Status,
@@ -201,51 +376,43 @@ func (p *ControlPlane) FinalizeOrchestration(
 	p.orchestrationStoreMu.Lock()
 	defer p.orchestrationStoreMu.Unlock()
 
-	orchestration, exists := p.orchestrationStore[orchestrationID]
-	if !exists {
-		return fmt.Errorf("control panel cannot finalize missing orchestration %s", orchestrationID)
+	orchestration, err := p.store.GetOrchestration(orchestrationID)
+	if err != nil {
+		return fmt.Errorf("control panel cannot finalize missing orchestration %s: %w", orchestrationID, err)
 	}
 
 	orchestration.Status = status
 	orchestration.Error = reason
 	orchestration.Results = results
 
+	if err := p.store.SaveOrchestration(orchestration); err != nil {
+		return fmt.Errorf("failed to save finalized orchestration %s: %w", orchestrationID, err)
+	}
+
 	p.Logger.Debug().
 		Str("OrchestrationID", orchestration.ID).
 		Msgf("About to FinalizeOrchestration with status: %s", orchestration.Status.String())
 
 	p.cleanupLogWorkers(orchestration.ID)
 
-	if err := p.triggerWebhook(orchestration); err != nil {
-		return fmt.Errorf("failed to trigger webhook for orchestration %s: %w", orchestration.ID, err)
+	if err := p.DispatchEvent(orchestration.ProjectID, newOrchestrationEvent(orchestration)); err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries for orchestration %s: %w", orchestration.ID, err)
 	}
 
 	return nil
 }
 
 func (p *ControlPlane) GetProjectByApiKey(key string) (*Project, error) {
-	apiKeyToProjectID := make(map[string]string)
-	for id, project := range p.projects {
-		apiKeyToProjectID[project.APIKey] = id
-	}
-
-	if projectID, exists := apiKeyToProjectID[key]; exists {
-		return p.projects[projectID], nil
-	} else {
-		return nil, fmt.Errorf("no project found with the given API key: %s", key)
-	}
+	project, _, err := p.AuthenticateAPIKey(key)
+	return project, err
 }
 
 func (p *ControlPlane) ServiceBelongsToProject(svcID, projectID string) bool {
 	p.servicesMu.RLock()
 	defer p.servicesMu.RUnlock()
 
-	projectServices, exists := p.services[projectID]
-	if !exists {
-		return false
-	}
-	_, ok := projectServices[svcID]
-	return ok
+	_, err := p.store.GetService(projectID, svcID)
+	return err == nil
 }
 
 func (p *ControlPlane) generateServiceKey(projectID string) string {
@@ -259,13 +426,12 @@ func (p *ControlPlane) discoverProjectServices(projectID string) ([]*ServiceInfo
 	p.servicesMu.RLock()
 	defer p.servicesMu.RUnlock()
 
-	var out []*ServiceInfo
-	projectServices, ok := p.services[projectID]
-	if !ok {
-		return nil, fmt.Errorf("no services found for project %s", projectID)
+	out, err := p.store.ListServices(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load services for project %s: %w", projectID, err)
 	}
-	for _, s := range projectServices {
-		out = append(out, s)
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no services found for project %s", projectID)
 	}
 	return out, nil
 }
@@ -298,10 +464,10 @@ Action Params:
 %s
 
 Guidelines:
-1. Each service described above contains input/output types and description. You must strictly adhere to these types and descriptions when using the services.
+1. Each service described above contains input/output types and description. Schemas follow JSON Schema: respect required fields, enum values, formats, nullable fields and the types of nested objects and array items. You must strictly adhere to these types and descriptions when using the services.
 2. Each task in the plan should strictly use one of the available services. Follow the JSON conventions for each task.
 3. Each task MUST have a unique ID, which is strictly increasing.
-4. With the excpetion of Task 0, whose inputs are constants derived from the User Action, inputs for other tasks have to be outputs from preceding tasks. In the latter case, use the format $taskId to denote the ID of the previous task whose output will be the input.
+4. With the excpetion of Task 0, whose inputs are constants derived from the User Action, inputs for other tasks have to be outputs from preceding tasks. In the latter case, use the format $taskId.field to select a field of the previous task's output, e.g. $task1.city. Nested fields and array elements can be selected too, e.g. $task1.address.city or $task1.items[0].name.
 5. There can only be a single Task 0, other tasks HAVE TO CORRESPOND TO AVAILABLE SERVICES.
 6. Ensure the plan maximizes parallelizability.
 7. Only use the provided services.
@@ -361,34 +527,33 @@ func (p *ControlPlane) decomposeAction(orchestration *Orchestration, services []
 		return nil, fmt.Errorf("error generating LLM prompt for decomposing actions: %v", err)
 	}
 
+	return p.completeCallingPlan(orchestration, prompt)
+}
+
+func (p *ControlPlane) completeCallingPlan(orchestration *Orchestration, prompt string) (*ServiceCallingPlan, error) {
 	p.Logger.Debug().
 		Str("Prompt", prompt).
 		Msg("Decompose action prompt")
 
-	client := openai.NewClient(p.openAIKey)
-	resp, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
-		Model: openai.GPT4oLatest,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleUser,
-				Content: prompt,
-			},
-		},
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), PlanningTimeout)
+	defer cancel()
 
+	content, err := p.llm.Complete(ctx, LLMRequest{
+		Prompt:     prompt,
+		SchemaName: callingPlanSchemaName,
+		Schema:     callingPlanSchema,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error calling OpenAI API: %v", err)
+		return nil, err
 	}
 
-	var result *ServiceCallingPlan
-	sanitisedJSON := sanitizeJSONOutput(resp.Choices[0].Message.Content)
 	p.Logger.Debug().
-		Str("Sanitized JSON", sanitisedJSON).
+		Str("Content", content).
 		Msg("Service calling plan")
 
-	err = json.Unmarshal([]byte(sanitisedJSON), &result)
+	result, err := parseCallingPlan(content)
 	if err != nil {
-		return nil, fmt.Errorf("error parsing LLM response as JSON: %v", err)
+		return nil, err
 	}
 
 	result.ProjectID = orchestration.ProjectID
@@ -402,20 +567,22 @@ func (p *ControlPlane) validateInput(services []*ServiceInfo, subTasks []*SubTas
 		serviceMap[service.ID] = service
 	}
 
+	var errs []error
 	for _, subTask := range subTasks {
 		service, ok := serviceMap[subTask.Service]
 		if !ok {
-			return fmt.Errorf("service %s not found for subtask %s", subTask.Service, subTask.ID)
+			errs = append(errs, fmt.Errorf("service %s not found for subtask %s", subTask.Service, subTask.ID))
+			continue
 		}
 
-		for inputKey := range subTask.Input {
+		for _, inputKey := range sortedKeys(subTask.Input) {
 			if !service.Schema.InputIncludes(inputKey) {
-				return fmt.Errorf("input %s not supported by service %s for subtask %s", inputKey, subTask.Service, subTask.ID)
+				errs = append(errs, fmt.Errorf("input %s not supported by service %s for subtask %s", inputKey, subTask.Service, subTask.ID))
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func (p *ControlPlane) addServiceDetails(services []*ServiceInfo, subTasks []*SubTask) error {
@@ -430,56 +597,79 @@ func (p *ControlPlane) addServiceDetails(services []*ServiceInfo, subTasks []*Su
 			return fmt.Errorf("service %s not found for subtask %s", subTask.Service, subTask.ID)
 		}
 		subTask.ServiceDetails = service.String()
+		subTask.ServiceVersion = service.Version
 	}
 
 	return nil
 }
 
-func (p *ControlPlane) createAndStartWorkers(orchestrationID string, plan *ServiceCallingPlan) {
+// pinServiceVersions swaps the services tasks are pinned to a version of for that version, a plan can only
+// pin a service to a single version. A pin only changes the schema the plan and task inputs are validated
+// against, tasks still run on the connected service, so versions with breaking changes to it are rejected.
+func (p *ControlPlane) pinServiceVersions(projectID string, services []*ServiceInfo, subTasks []*SubTask) ([]*ServiceInfo, error) {
+	pins := make(map[string]int64)
+	for _, subTask := range subTasks {
+		if subTask.ServiceVersion == 0 {
+			continue
+		}
+		if pinned, ok := pins[subTask.Service]; ok && pinned != subTask.ServiceVersion {
+			return nil, fmt.Errorf("service %s is pinned to versions %d and %d", subTask.Service, pinned, subTask.ServiceVersion)
+		}
+		pins[subTask.Service] = subTask.ServiceVersion
+	}
+	if len(pins) == 0 {
+		return services, nil
+	}
+
+	pinned := make([]*ServiceInfo, len(services))
+	for i, service := range services {
+		pinned[i] = service
+		if version, ok := pins[service.ID]; ok && version != service.Version {
+			versioned, err := p.GetServiceVersion(projectID, service.ID, version)
+			if err != nil {
+				return nil, err
+			}
+			if breaking := breakingChanges(compareServiceSchemas(versioned.Schema, service.Schema)); len(breaking) > 0 {
+				return nil, fmt.Errorf("service %s version %d is incompatible with the running version %d: %s", service.ID, version, service.Version, joinChanges(breaking))
+			}
+			pinned[i] = versioned
+		}
+	}
+	return pinned, nil
+}
+
+// createAndStartWorkers starts the workers executing the plan, it fails when the plan cannot be scheduled.
+func (p *ControlPlane) createAndStartWorkers(orchestrationID string, plan *ServiceCallingPlan) error {
 	p.workerMu.Lock()
 	defer p.workerMu.Unlock()
 
 	p.logWorkers[orchestrationID] = make(map[string]context.CancelFunc)
 
-	resultDependencies := make(DependencyKeys)
+	// Plans prepared before execution DAGs were introduced are scheduled when recovered
+	dag := plan.DAG
+	if dag == nil {
+		var err error
+		if dag, err = newExecutionDAG(plan); err != nil {
+			return fmt.Errorf("error scheduling calling plan: %w", err)
+		}
+	}
 
+	tasks := make(map[string]*SubTask, len(plan.Tasks))
 	for _, task := range plan.Tasks {
-		deps := task.extractDependencies()
-		resultDependencies[task.ID] = struct{}{}
+		tasks[task.ID] = task
+	}
 
-		p.Logger.Debug().
-			Fields(map[string]any{
-				"TaskID":          task.ID,
-				"Dependencies":    deps,
-				"OrchestrationID": orchestrationID,
-			}).
-			Msg("Task extracted dependencies")
-
-		worker := NewTaskWorker(task.Service, task.ID, deps, p.LogManager)
-		ctx, cancel := context.WithCancel(context.Background())
-		p.logWorkers[orchestrationID][task.ID] = cancel
-		p.Logger.Debug().
-			Fields(struct {
-				TaskID          string
-				OrchestrationID string
-			}{
-				TaskID:          task.ID,
-				OrchestrationID: orchestrationID,
-			}).
-			Msg("Starting worker for task")
+	resultDependencies := make(DependencyKeys)
 
-		go worker.Start(ctx, orchestrationID)
+	for _, level := range dag.Levels {
+		for _, taskID := range level {
+			p.startTaskWorker(orchestrationID, tasks[taskID], dag.dependencyKeys(taskID))
+			resultDependencies[taskID] = struct{}{}
+		}
 	}
 
 	if len(resultDependencies) == 0 {
-		p.Logger.Error().
-			Fields(map[string]any{
-				"Dependencies":    resultDependencies,
-				"OrchestrationID": orchestrationID,
-			}).
-			Msg("Result Aggregator has no dependencies")
-
-		return
+		return fmt.Errorf("error scheduling calling plan: result aggregator has no dependencies")
 	}
 
 	p.Logger.Debug().
@@ -502,6 +692,33 @@ func (p *ControlPlane) createAndStartWorkers(orchestrationID string, plan *Servi
 
 	p.Logger.Debug().Str("orchestrationID", orchestrationID).Msg("Starting failure tracker for orchestration")
 	go fTracker.Start(fCtx, orchestrationID)
+	return nil
+}
+
+// startTaskWorker starts the task's worker, callers must hold workerMu.
+func (p *ControlPlane) startTaskWorker(orchestrationID string, task *SubTask, deps DependencyKeys) {
+	p.Logger.Debug().
+		Fields(map[string]any{
+			"TaskID":          task.ID,
+			"Dependencies":    deps,
+			"OrchestrationID": orchestrationID,
+		}).
+		Msg("Task extracted dependencies")
+
+	worker := NewTaskWorker(task.Service, task.ServiceVersion, task.ID, deps, task.Input, p.LogManager)
+	ctx, cancel := context.WithCancel(context.Background())
+	p.logWorkers[orchestrationID][task.ID] = cancel
+	p.Logger.Debug().
+		Fields(struct {
+			TaskID          string
+			OrchestrationID string
+		}{
+			TaskID:          task.ID,
+			OrchestrationID: orchestrationID,
+		}).
+		Msg("Starting worker for task")
+
+	go worker.Start(ctx, orchestrationID)
 }
 
 func (p *ControlPlane) cleanupLogWorkers(orchestrationID string) {
@@ -523,6 +740,25 @@ func (p *ControlPlane) cleanupLogWorkers(orchestrationID string) {
 	}
 }
 
+// saveOrchestration persists the orchestration unless its project was deleted meanwhile, callers should hold
+// the orchestrationStoreMu lock.
+func (p *ControlPlane) saveOrchestration(orchestration *Orchestration) {
+	if _, err := p.store.GetProject(orchestration.ProjectID); errors.Is(err, ErrNotFound) {
+		p.Logger.Debug().
+			Str("OrchestrationID", orchestration.ID).
+			Str("ProjectID", orchestration.ProjectID).
+			Msg("Skipped saving orchestration of a deleted project")
+		return
+	}
+
+	if err := p.store.SaveOrchestration(orchestration); err != nil {
+		p.Logger.Error().
+			Str("OrchestrationID", orchestration.ID).
+			Err(err).
+			Msg("Failed to save orchestration")
+	}
+}
+
 func (p *ControlPlane) callingPlanMinusTaskZero(callingPlan *ServiceCallingPlan) (*SubTask, *ServiceCallingPlan) {
 	var taskZero *SubTask
 	var serviceTasks []*SubTask
@@ -546,80 +782,6 @@ func (p *ControlPlane) cannotExecuteAction(subTasks []*SubTask) bool {
 	return len(subTasks) == 1 && strings.EqualFold(subTasks[0].ID, "final")
 }
 
-func (p *ControlPlane) triggerWebhook(orchestration *Orchestration) error {
-	project, ok := p.projects[orchestration.ProjectID]
-	if !ok {
-		return fmt.Errorf("project %s not found", orchestration.ProjectID)
-	}
-
-	var payload = struct {
-		OrchestrationID string            `json:"orchestrationId"`
-		Results         []json.RawMessage `json:"results"`
-		Status          Status            `json:"status"`
-		Error           json.RawMessage   `json:"error,omitempty"`
-	}{
-		OrchestrationID: orchestration.ID,
-		Results:         orchestration.Results,
-		Status:          orchestration.Status,
-		Error:           orchestration.Error,
-	}
-
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to trigger webhook failed to marshal payload: %w", err)
-	}
-
-	p.Logger.Debug().
-		Fields(struct {
-			OrchestrationID string
-			ProjectID       string
-			Webhook         string
-			Payload         string
-		}{
-			OrchestrationID: orchestration.ID,
-			ProjectID:       project.ID,
-			Webhook:         project.Webhook,
-			Payload:         string(jsonPayload),
-		}).
-		Msg("Triggering webhook")
-
-	// Create a new request
-	req, err := http.NewRequest("POST", project.Webhook, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Orra/1.0")
-
-	// Create an HTTP client with a timeout
-	client := &http.Client{
-		Timeout: time.Second * 10,
-	}
-
-	// Send the request
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			p.Logger.Error().
-				Str("OrchestrationID", orchestration.ID).
-				Err(fmt.Errorf("failed to close response body when triggering Webhook: %w", err))
-		}
-	}(resp.Body)
-
-	// Check the response status
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	return nil
-}
-
 func (s ServiceSchema) InputIncludes(src string) bool {
 	return s.Input.IncludesProp(src)
 }
@@ -649,26 +811,6 @@ func (si *ServiceInfo) String() string {
 	return fmt.Sprintf("[%s] %s - %s", si.Type.String(), si.Name, si.Description)
 }
 
-func sanitizeJSONOutput(input string) string {
-	// Remove leading and trailing whitespace
-	trimmed := strings.TrimSpace(input)
-
-	// Check if the input starts and ends with JSON markdown markers
-	if strings.HasPrefix(trimmed, "```json") && strings.HasSuffix(trimmed, "```") {
-		// Remove the starting "```json" marker
-		withoutStart := strings.TrimPrefix(trimmed, "```json")
-
-		// Remove the ending "```" marker
-		withoutEnd := strings.TrimSuffix(withoutStart, "```")
-
-		// Trim any remaining whitespace
-		return strings.TrimSpace(withoutEnd)
-	}
-
-	// If the input doesn't have the expected markers, return it as is
-	return input
-}
-
 func (o *Orchestration) Executable() bool {
 	return o.Status != NotActionable && o.Status != Failed
 }
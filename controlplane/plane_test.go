@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -28,3 +31,41 @@ func TestExtractDependencyID(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteOrchestrationFailsWhenLogCannotBeCreated(t *testing.T) {
+	plane, store := newTestPlane(t, NewFakeLLM(echoPlan), echoService())
+	_ = store.SaveProject(&Project{ID: "p1"})
+	// Logs cannot be persisted under a regular file
+	logsDir := filepath.Join(t.TempDir(), "logs")
+	if err := os.WriteFile(logsDir, nil, 0o600); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	startTestPlane(t, plane, logsDir)
+
+	orchestration := echoOrchestration("o1", "hi")
+	plane.PrepareOrchestration(orchestration)
+	plane.ExecuteOrchestration(orchestration)
+
+	saved, err := store.GetOrchestration("o1")
+	if err != nil || saved.Status != Failed || !strings.Contains(string(saved.Error), "error creating Log") {
+		t.Errorf("expected the orchestration to fail, got %+v, err %v", saved, err)
+	}
+}
+
+func TestExecuteOrchestrationFailsWhenPlanCannotBeScheduled(t *testing.T) {
+	plane, store := newTestPlane(t, nil, echoService())
+	_ = store.SaveProject(&Project{ID: "p1"})
+	startTestPlane(t, plane, t.TempDir())
+
+	orchestration := echoOrchestration("o1", "hi")
+	orchestration.Plan = &ServiceCallingPlan{ProjectID: "p1", Tasks: []*SubTask{
+		{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task2.echo"}},
+		{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+	}}
+	plane.ExecuteOrchestration(orchestration)
+
+	saved, err := store.GetOrchestration("o1")
+	if err != nil || saved.Status != Failed || !strings.Contains(string(saved.Error), "error scheduling calling plan") {
+		t.Errorf("expected the orchestration to fail, got %+v, err %v", saved, err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const callingPlanSchemaName = "service_calling_plan"
+
+// callingPlanSchema describes a ServiceCallingPlan, it is sent to LLM providers supporting structured output.
+var callingPlanSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "tasks": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string", "description": "task0 for the action's data, task1, task2, ... for service calls, or final"},
+          "service": {"type": "string", "description": "The ID of the service to call"},
+          "input": {
+            "type": "object",
+            "description": "Input field names mapped to literal values or $taskN.field references",
+            "additionalProperties": {"type": "string"}
+          }
+        },
+        "required": ["id", "input"]
+      }
+    },
+    "parallel_groups": {
+      "type": "array",
+      "items": {"type": "array", "items": {"type": "string"}}
+    }
+  },
+  "required": ["tasks"]
+}`)
+
+// parseCallingPlan parses an LLM reply into a ServiceCallingPlan. Replies are expected to be the plan's JSON,
+// when they are not, e.g. the JSON is fenced in markdown or surrounded by prose, the first JSON object in the
+// reply that parses as a plan is used.
+func parseCallingPlan(content string) (*ServiceCallingPlan, error) {
+	var plan *ServiceCallingPlan
+	trimmed := strings.TrimSpace(content)
+	err := json.Unmarshal([]byte(trimmed), &plan)
+	if err == nil && plan != nil {
+		return plan, nil
+	}
+
+	for start := strings.IndexByte(trimmed, '{'); start >= 0; {
+		candidate, ok := firstJSONObject(trimmed[start:])
+		if ok {
+			plan = nil
+			if json.Unmarshal([]byte(candidate), &plan) == nil && plan != nil {
+				return plan, nil
+			}
+		}
+
+		next := strings.IndexByte(trimmed[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+
+	if err == nil {
+		err = errors.New("no plan found")
+	}
+	return nil, fmt.Errorf("error parsing LLM response as JSON: %v", err)
+}
+
+// firstJSONObject returns the balanced {...} object s starts with, braces inside JSON strings are ignored.
+func firstJSONObject(s string) (string, bool) {
+	depth := 0
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return s[:i+1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// repairCallingPlan asks the LLM to correct a calling plan rejected for the given reason.
+func (p *ControlPlane) repairCallingPlan(orchestration *Orchestration, services []*ServiceInfo, rejected *ServiceCallingPlan, reason error) (*ServiceCallingPlan, error) {
+	prompt, err := p.generateLLMPrompt(orchestration, services)
+	if err != nil {
+		return nil, fmt.Errorf("error generating LLM prompt for repairing the plan: %v", err)
+	}
+
+	rejectedStr, err := json.Marshal(rejected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rejected plan: %w", err)
+	}
+
+	prompt = fmt.Sprintf(`%s
+
+The following execution plan was rejected:
+%s
+
+Errors:
+%s
+
+Correct every error, only use the services and input fields listed above, and generate the corrected execution plan:`,
+		prompt,
+		string(rejectedStr),
+		reason.Error(),
+	)
+
+	return p.completeCallingPlan(orchestration, prompt)
+}
+
+func (o *Orchestration) recordPlanningAttempt(attempt int, plan *ServiceCallingPlan, err error) {
+	record := PlanningAttempt{Attempt: attempt, Plan: plan, Timestamp: time.Now().UTC()}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	o.PlanningAttempts = append(o.PlanningAttempts, record)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (plan *ServiceCallingPlan) clone() *ServiceCallingPlan {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil
+	}
+
+	var out *ServiceCallingPlan
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	out.ProjectID = plan.ProjectID
+	return out
+}
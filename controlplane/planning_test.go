@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCallingPlanToleratesSurroundingText(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"plain", `{"tasks":[{"id":"task1","service":"s1","input":{"a":"b"}}]}`, "s1"},
+		{"fenced", "```json\n" + `{"tasks":[{"id":"task1","service":"s1","input":{}}]}` + "\n```", "s1"},
+		{"prose", `Sure! Here is the plan: {"tasks":[{"id":"task1","service":"s1","input":{}}]} Let me know.`, "s1"},
+		{"braces in strings", `Plan {draft}: {"tasks":[{"id":"task1","service":"s}1","input":{"a":"{\"x\""}}]}`, "s}1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := parseCallingPlan(tc.content)
+			if err != nil {
+				t.Fatalf("failed to parse plan: %v", err)
+			}
+			if len(plan.Tasks) != 1 || plan.Tasks[0].Service != tc.want {
+				t.Errorf("unexpected plan: %+v", plan)
+			}
+		})
+	}
+
+	for _, content := range []string{"", "I cannot plan this", `{"tasks": [`} {
+		if _, err := parseCallingPlan(content); err == nil {
+			t.Errorf("expected an error parsing %q", content)
+		}
+	}
+}
+
+func TestPrepareOrchestrationRepairsRejectedPlans(t *testing.T) {
+	const (
+		hallucinated = `{"tasks":[{"id":"task0","input":{"message":"hi"}},{"id":"task1","service":"s1","input":{"msg":"$task0.message"}}],"parallel_groups":[["task1"]]}`
+		corrected    = `{"tasks":[{"id":"task0","input":{"message":"hi"}},{"id":"task1","service":"s1","input":{"message":"$task0.message"}}],"parallel_groups":[["task1"]]}`
+	)
+
+	llm := NewFakeLLM(hallucinated, corrected)
+	plane, _ := newTestPlane(t, llm, echoService())
+	orchestration := echoOrchestration("o1", "hi")
+	plane.PrepareOrchestration(orchestration)
+
+	if !orchestration.Executable() || orchestration.Plan.Tasks[0].Input["message"] != "$task0.message" {
+		t.Fatalf("expected the repaired plan to be executable, got %s: %s", orchestration.Status.String(), orchestration.Error)
+	}
+	attempts := orchestration.PlanningAttempts
+	if len(attempts) != 2 || !strings.Contains(attempts[0].Error, "input msg not supported") || attempts[1].Error != "" {
+		t.Errorf("unexpected planning attempts: %+v", attempts)
+	}
+	if prompts := llm.Prompts(); len(prompts) != 2 || !strings.Contains(prompts[1], "input msg not supported") || !strings.Contains(prompts[1], `"msg":"$task0.message"`) {
+		t.Errorf("expected the repair prompt to include the rejected plan and its errors, got %q", prompts)
+	}
+
+	plane, _ = newTestPlane(t, NewFakeLLM(hallucinated), echoService())
+	orchestration = echoOrchestration("o1", "hi")
+	plane.PrepareOrchestration(orchestration)
+
+	if orchestration.Status != Failed || !strings.Contains(string(orchestration.Error), "input msg not supported") {
+		t.Errorf("expected the orchestration to fail once repairs are exhausted, got %s: %s", orchestration.Status.String(), orchestration.Error)
+	}
+	if len(orchestration.PlanningAttempts) != MaxPlanRepairAttempts+1 {
+		t.Errorf("expected %d planning attempts, got %d", MaxPlanRepairAttempts+1, len(orchestration.PlanningAttempts))
+	}
+}
+
+func TestPrepareOrchestrationDoesNotLockOrchestrationsWhilePlanning(t *testing.T) {
+	llm := newBlockingLLM(echoPlan)
+	plane, store := newTestPlane(t, llm, echoService())
+	_ = store.SaveProject(&Project{ID: "p1"})
+	_ = store.SaveOrchestration(&Orchestration{ID: "o2", ProjectID: "p1", Status: Processing})
+
+	prepared := make(chan struct{})
+	go func() {
+		defer close(prepared)
+		plane.PrepareOrchestration(echoOrchestration("o1", "hi"))
+	}()
+	<-llm.called
+
+	finalized := make(chan error, 1)
+	go func() {
+		finalized <- plane.FinalizeOrchestration("o2", Completed, nil, nil)
+	}()
+	select {
+	case err := <-finalized:
+		if err != nil {
+			t.Fatalf("failed to finalize orchestration: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("finalizing an orchestration waited for another orchestration to be planned")
+	}
+
+	close(llm.release)
+	<-prepared
+	if saved, err := store.GetOrchestration("o1"); err != nil || !saved.Executable() {
+		t.Errorf("expected the planned orchestration to be saved, got %+v, err %v", saved, err)
+	}
+}
+
+func TestPlanOrchestrationReturnsPlanWithoutSavingIt(t *testing.T) {
+	plane, store := newTestPlane(t, NewFakeLLM(echoPlan), echoService())
+
+	orchestration := echoOrchestration("o1", "hi")
+	plane.PlanOrchestration(orchestration)
+
+	if orchestration.Status != Pending || orchestration.Plan == nil || len(orchestration.Plan.Tasks) != 1 {
+		t.Fatalf("expected a planned orchestration, got %s: %+v", orchestration.Status.String(), orchestration.Plan)
+	}
+	if _, err := store.GetOrchestration("o1"); err == nil {
+		t.Errorf("expected a planned orchestration not to be saved")
+	}
+}
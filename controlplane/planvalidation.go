@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// validateCallingPlan statically checks a calling plan before it's executed. It reports every
+// reference to a missing task or to a path missing from a task's output, parallel groups that
+// don't match the tasks and their dependencies, and dependency cycles. Any of these would leave
+// the plan's task workers waiting forever.
+func validateCallingPlan(services []*ServiceInfo, taskZero *SubTask, plan *ServiceCallingPlan) error {
+	serviceMap := make(map[string]*ServiceInfo)
+	for _, service := range services {
+		serviceMap[service.ID] = service
+	}
+
+	var errs []error
+	tasks := make(map[string]*SubTask)
+	for _, subTask := range plan.Tasks {
+		if _, exists := tasks[subTask.ID]; exists {
+			errs = append(errs, fmt.Errorf("task %s is defined more than once", subTask.ID))
+			continue
+		}
+		tasks[subTask.ID] = subTask
+	}
+
+	for _, subTask := range plan.Tasks {
+		for _, inputKey := range sortedKeys(subTask.Input) {
+			source := string(subTask.Input[inputKey])
+			taskID, field, ok := parseTaskReference(source)
+			if !ok {
+				continue
+			}
+
+			if strings.EqualFold(taskID, TaskZero) {
+				if _, exists := taskZero.Input[field]; !exists {
+					errs = append(errs, fmt.Errorf("input %s of task %s references %s but task0 has no %s field", inputKey, subTask.ID, source, field))
+				}
+				continue
+			}
+
+			dependency, exists := tasks[taskID]
+			if !exists {
+				errs = append(errs, fmt.Errorf("input %s of task %s references %s but there is no task %s", inputKey, subTask.ID, source, taskID))
+				continue
+			}
+
+			service, exists := serviceMap[dependency.Service]
+			if !exists || len(service.Schema.Output.Properties) == 0 {
+				continue
+			}
+			steps, err := parsePath(strings.TrimPrefix(source, "$"+taskID+"."))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("input %s of task %s references %s but it %w", inputKey, subTask.ID, source, err))
+				continue
+			}
+			if problem := service.Schema.Output.pathProblem(steps); problem != "" {
+				errs = append(errs, fmt.Errorf("input %s of task %s references %s but service %s of task %s %s", inputKey, subTask.ID, source, dependency.Service, taskID, problem))
+			}
+		}
+	}
+
+	errs = append(errs, validateParallelGroups(plan, tasks)...)
+
+	if cycle := findDependencyCycle(plan, tasks); len(cycle) > 0 {
+		errs = append(errs, fmt.Errorf("tasks depend on each other in a cycle: %s", strings.Join(cycle, " -> ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateParallelGroups checks each service task is in a single parallel group following the groups of the
+// tasks it depends on. Plans without parallel groups are accepted.
+func validateParallelGroups(plan *ServiceCallingPlan, tasks map[string]*SubTask) []error {
+	if len(plan.ParallelGroups) == 0 {
+		return nil
+	}
+
+	var errs []error
+	groupOf := make(map[string]int)
+	for i, group := range plan.ParallelGroups {
+		for _, taskID := range group {
+			if strings.EqualFold(taskID, TaskZero) {
+				continue
+			}
+			if _, exists := tasks[taskID]; !exists {
+				errs = append(errs, fmt.Errorf("parallel_groups[%d] references %s but there is no task %s", i, taskID, taskID))
+				continue
+			}
+			if previous, seen := groupOf[taskID]; seen {
+				errs = append(errs, fmt.Errorf("task %s is in both parallel_groups[%d] and parallel_groups[%d]", taskID, previous, i))
+				continue
+			}
+			groupOf[taskID] = i
+		}
+	}
+
+	for _, subTask := range plan.Tasks {
+		group, grouped := groupOf[subTask.ID]
+		if !grouped {
+			errs = append(errs, fmt.Errorf("task %s is not in any of the parallel_groups", subTask.ID))
+			continue
+		}
+
+		for _, dependency := range sortedKeys(subTask.extractDependencies()) {
+			if dependencyGroup, grouped := groupOf[dependency]; grouped && dependencyGroup >= group {
+				errs = append(errs, fmt.Errorf("task %s in parallel_groups[%d] depends on task %s which is not in an earlier group", subTask.ID, group, dependency))
+			}
+		}
+	}
+
+	return errs
+}
+
+// findDependencyCycle returns the task IDs forming the first dependency cycle found, with the
+// cycle's first task repeated at the end, or nil when the plan's tasks are acyclic.
+func findDependencyCycle(plan *ServiceCallingPlan, tasks map[string]*SubTask) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	var path []string
+
+	var visit func(taskID string) []string
+	visit = func(taskID string) []string {
+		state[taskID] = visiting
+		path = append(path, taskID)
+
+		for _, dependency := range sortedKeys(tasks[taskID].extractDependencies()) {
+			if _, exists := tasks[dependency]; !exists {
+				continue
+			}
+			switch state[dependency] {
+			case visiting:
+				for i, id := range path {
+					if id == dependency {
+						return append(append([]string(nil), path[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[taskID] = visited
+		return nil
+	}
+
+	for _, subTask := range plan.Tasks {
+		if state[subTask.ID] != unvisited {
+			continue
+		}
+		if cycle := visit(subTask.ID); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// pathProblem walks an output path through the spec's properties and array items, reporting the first step
+// the spec rules out. Parts of the spec without a declared shape accept any path.
+func (s Spec) pathProblem(steps []pathStep) string {
+	spec, selected := s, ""
+	for _, step := range steps {
+		if step.field != "" {
+			if len(spec.Properties) == 0 {
+				if spec.Type != "" && spec.Type != "object" {
+					return fmt.Sprintf("output %s is not an object", selected)
+				}
+				return ""
+			}
+
+			property, exists := spec.Properties[step.field]
+			if !exists && selected == "" {
+				return fmt.Sprintf("has no %s output field", step.field)
+			}
+			if !exists {
+				return fmt.Sprintf("output %s has no %s field", selected, step.field)
+			}
+			spec, selected = property, strings.TrimPrefix(selected+"."+step.field, ".")
+			continue
+		}
+
+		if spec.Type != "array" {
+			if spec.Type != "" {
+				return fmt.Sprintf("output %s is not an array", selected)
+			}
+			return ""
+		}
+		if spec.Items == nil {
+			return ""
+		}
+		spec, selected = *spec.Items, fmt.Sprintf("%s[%d]", selected, step.index)
+	}
+	return ""
+}
+
+// parseTaskReference splits a "$taskId.field" input source into the task ID and the referenced field.
+func parseTaskReference(source string) (string, string, bool) {
+	taskID := extractDependencyID(source)
+	if taskID == "" {
+		return "", "", false
+	}
+
+	field := strings.TrimPrefix(source, "$"+taskID+".")
+	if i := strings.IndexAny(field, ".["); i >= 0 {
+		field = field[:i]
+	}
+	return taskID, field, true
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCallingPlan(t *testing.T) {
+	services := []*ServiceInfo{
+		{
+			ID: "s1",
+			Schema: ServiceSchema{
+				Input: Spec{Type: "object", Properties: Properties{"message": {Type: "string"}}},
+				Output: Spec{Type: "object", Properties: Properties{
+					"echo": {Type: "string"},
+					"sender": {Type: "object", Properties: Properties{
+						"name":      {Type: "string"},
+						"addresses": {Type: "array", Items: &Spec{Type: "object", Properties: Properties{"city": {Type: "string"}}}},
+					}},
+					"metadata": {Type: "object"},
+				}},
+			},
+		},
+	}
+	taskZero := &SubTask{ID: TaskZero, Input: map[string]Source{"message": "hi"}}
+
+	testCases := []struct {
+		name   string
+		tasks  []*SubTask
+		groups []ParallelGroup
+		want   []string
+	}{
+		{
+			name: "valid",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+				{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+			},
+			groups: []ParallelGroup{{"task1"}, {"task2"}},
+		},
+		{
+			name: "dangling references",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.text"}},
+				{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task3.echo"}},
+			},
+			want: []string{
+				"input message of task task1 references $task0.text but task0 has no text field",
+				"input message of task task2 references $task3.echo but there is no task task3",
+			},
+		},
+		{
+			name: "missing output field",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+				{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.reply.text"}},
+			},
+			want: []string{"input message of task task2 references $task1.reply.text but service s1 of task task1 has no reply output field"},
+		},
+		{
+			name: "nested output paths",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+				{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.sender.addresses[0].city"}},
+				{ID: "task3", Service: "s1", Input: map[string]Source{"message": "$task1.metadata.anything[2]"}},
+			},
+		},
+		{
+			name: "missing nested output paths",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+				{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.sender.addresses[0].zip"}},
+				{ID: "task3", Service: "s1", Input: map[string]Source{"message": "$task1.sender.name[0]"}},
+				{ID: "task4", Service: "s1", Input: map[string]Source{"message": "$task1.echo.text"}},
+				{ID: "task5", Service: "s1", Input: map[string]Source{"message": "$task1.sender..name"}},
+			},
+			want: []string{
+				"input message of task task2 references $task1.sender.addresses[0].zip but service s1 of task task1 output sender.addresses[0] has no zip field",
+				"input message of task task3 references $task1.sender.name[0] but service s1 of task task1 output sender.name is not an array",
+				"input message of task task4 references $task1.echo.text but service s1 of task task1 output echo is not an object",
+				`input message of task task5 references $task1.sender..name but it has a malformed path "sender..name"`,
+			},
+		},
+		{
+			name: "cycle",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task3.echo"}},
+				{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+				{ID: "task3", Service: "s1", Input: map[string]Source{"message": "$task2.echo"}},
+			},
+			want: []string{"tasks depend on each other in a cycle: task1 -> task3 -> task2 -> task1"},
+		},
+		{
+			name: "inconsistent parallel groups",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+				{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+				{ID: "task3", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+			},
+			groups: []ParallelGroup{{"task0", "task1", "task2"}, {"task1", "task4"}},
+			want: []string{
+				"task task1 is in both parallel_groups[0] and parallel_groups[1]",
+				"parallel_groups[1] references task4 but there is no task task4",
+				"task task2 in parallel_groups[0] depends on task task1 which is not in an earlier group",
+				"task task3 is not in any of the parallel_groups",
+			},
+		},
+		{
+			name: "duplicate tasks",
+			tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+			},
+			want: []string{"task task1 is defined more than once"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCallingPlan(services, taskZero, &ServiceCallingPlan{Tasks: tc.tasks, ParallelGroups: tc.groups})
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Errorf("expected a valid plan, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q", tc.want)
+			}
+			if got := strings.Split(err.Error(), "\n"); strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Errorf("got errors:\n%s\nwant:\n%s", err, strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type ProjectUpdate struct {
+	Name *string `json:"name"`
+}
+
+type ProjectDetails struct {
+	*Project
+	Services       []*ServiceSummary `json:"services"`
+	Orchestrations int               `json:"orchestrations"`
+}
+
+type ServiceSummary struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Type    ServiceType `json:"type"`
+	Version int64       `json:"version"`
+}
+
+// ListProjects returns all the registered projects ordered by name.
+func (p *ControlPlane) ListProjects() ([]*Project, error) {
+	p.projectsMu.RLock()
+	defer p.projectsMu.RUnlock()
+
+	projects, err := p.store.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load projects: %w", err)
+	}
+
+	slices.SortFunc(projects, func(a, b *Project) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return projects, nil
+}
+
+func (p *ControlPlane) GetProject(projectID string) (*Project, error) {
+	p.projectsMu.RLock()
+	defer p.projectsMu.RUnlock()
+	return p.store.GetProject(projectID)
+}
+
+// GetProjectDetails returns the project with a summary of its services and the number of its orchestrations.
+func (p *ControlPlane) GetProjectDetails(projectID string) (*ProjectDetails, error) {
+	p.projectsMu.RLock()
+	project, err := p.store.GetProject(projectID)
+	p.projectsMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	p.servicesMu.RLock()
+	services, err := p.store.ListServices(projectID)
+	p.servicesMu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load services for project %s: %w", projectID, err)
+	}
+
+	orchestrations, err := p.store.ListOrchestrations(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load orchestrations for project %s: %w", projectID, err)
+	}
+
+	details := &ProjectDetails{
+		Project:        project,
+		Services:       make([]*ServiceSummary, 0, len(services)),
+		Orchestrations: len(orchestrations),
+	}
+	for _, service := range services {
+		details.Services = append(details.Services, &ServiceSummary{
+			ID:      service.ID,
+			Name:    service.Name,
+			Type:    service.Type,
+			Version: service.Version,
+		})
+	}
+	slices.SortFunc(details.Services, func(a, b *ServiceSummary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return details, nil
+}
+
+// UpdateProject applies the non nil fields of the update to the project.
+func (p *ControlPlane) UpdateProject(projectID string, update ProjectUpdate) (*Project, error) {
+	p.projectsMu.Lock()
+	defer p.projectsMu.Unlock()
+
+	project, err := p.store.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		project.Name = strings.TrimSpace(*update.Name)
+	}
+
+	if err := p.store.SaveProject(project); err != nil {
+		return nil, fmt.Errorf("failed to save project %s: %w", project.ID, err)
+	}
+
+	p.Logger.Debug().Str("ProjectID", project.ID).Msg("Updated project")
+	return project, nil
+}
+
+// DeleteProject stops the project's running orchestrations, disconnects its services and
+// removes the project along with everything stored for it.
+func (p *ControlPlane) DeleteProject(projectID string) error {
+	p.projectsMu.Lock()
+	defer p.projectsMu.Unlock()
+
+	if _, err := p.store.GetProject(projectID); err != nil {
+		return err
+	}
+
+	orchestrations, err := p.store.ListOrchestrations(projectID)
+	if err != nil {
+		return fmt.Errorf("failed to load orchestrations for project %s: %w", projectID, err)
+	}
+	for _, orchestration := range orchestrations {
+		p.cleanupLogWorkers(orchestration.ID)
+		if err := p.LogManager.RemoveLog(orchestration.ID); err != nil {
+			p.Logger.Error().Err(err).Str("OrchestrationID", orchestration.ID).Msg("Failed to remove orchestration Log")
+		}
+	}
+
+	services, err := p.disconnectProjectServices(projectID)
+	if err != nil {
+		return err
+	}
+
+	// Services are disconnected before taking orchestrationStoreMu so the two locks are never held together
+	p.orchestrationStoreMu.Lock()
+	err = p.store.DeleteProject(projectID)
+	p.orchestrationStoreMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to delete project %s: %w", projectID, err)
+	}
+	p.removeProjectAPIKeys(projectID)
+
+	p.Logger.Info().
+		Str("ProjectID", projectID).
+		Int("Services", len(services)).
+		Int("Orchestrations", len(orchestrations)).
+		Msg("Deleted project")
+	return nil
+}
+
+func (p *ControlPlane) disconnectProjectServices(projectID string) ([]*ServiceInfo, error) {
+	p.servicesMu.Lock()
+	defer p.servicesMu.Unlock()
+
+	services, err := p.store.ListServices(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load services for project %s: %w", projectID, err)
+	}
+	for _, service := range services {
+		p.WebSocketManager.RemoveService(service.ID)
+	}
+	return services, nil
+}
+
+func (p *Project) clone() *Project {
+	out := *p
+	out.Webhooks = make([]*Webhook, 0, len(p.Webhooks))
+	for _, webhook := range p.Webhooks {
+		w := *webhook
+		w.Events = slices.Clone(webhook.Events)
+		out.Webhooks = append(out.Webhooks, &w)
+	}
+	return &out
+}
+
+func validateWebhook(webhook string) error {
+	parsed, err := url.ParseRequestURI(webhook)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL: %s", webhook)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingLLM replies once released, signalling every call it receives.
+type blockingLLM struct {
+	reply   string
+	called  chan struct{}
+	release chan struct{}
+}
+
+func newBlockingLLM(reply string) *blockingLLM {
+	return &blockingLLM{reply: reply, called: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (l *blockingLLM) Complete(ctx context.Context, _ LLMRequest) (string, error) {
+	select {
+	case l.called <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-l.release:
+		return l.reply, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func TestDeleteProjectWhileOrchestrationIsPlanned(t *testing.T) {
+	// Validating a plan pinning a previous service version reads the services again after planning
+	llm := newBlockingLLM(`{"tasks":[{"id":"task0","input":{"message":"hi"}},{"id":"task1","service":"s1","service_version":1,"input":{"message":"$task0.message"}}]}`)
+	latest := echoService()
+	latest.Version = 2
+	plane, store := newTestPlane(t, llm, echoService(), latest)
+	_ = store.SaveProject(&Project{ID: "p1"})
+	startTestPlane(t, plane, t.TempDir())
+
+	prepared := make(chan struct{})
+	go func() {
+		defer close(prepared)
+		plane.PrepareOrchestration(echoOrchestration("o1", "hi"))
+	}()
+	<-llm.called
+
+	deleted := make(chan error, 1)
+	go func() {
+		deleted <- plane.DeleteProject("p1")
+	}()
+	// Give the deletion time to contend for the locks held or taken while planning
+	time.Sleep(50 * time.Millisecond)
+	close(llm.release)
+
+	select {
+	case <-prepared:
+	case <-time.After(5 * time.Second):
+		t.Fatal("planning an orchestration while its project is deleted deadlocked")
+	}
+	select {
+	case err := <-deleted:
+		if err != nil {
+			t.Fatalf("failed to delete project: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("deleting a project while an orchestration is planned deadlocked")
+	}
+
+	if _, err := store.GetProject("p1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the project to be deleted, got err %v", err)
+	}
+	if _, err := store.GetOrchestration("o1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the orchestration of the deleted project not to be saved, got err %v", err)
+	}
+}
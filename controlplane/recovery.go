@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RecoverOrchestrations resumes every orchestration that was still processing when the control plane stopped.
+// Each orchestration's Log is reloaded from disk and its workers are restarted, workers skip tasks that
+// already have an output in the Log and pick up from their last saved state. Orchestrations still pending,
+// i.e. planned but never started, are executed when they have a validated plan and failed otherwise.
+func (p *ControlPlane) RecoverOrchestrations() error {
+	projects, err := p.store.ListProjects()
+	if err != nil {
+		return fmt.Errorf("failed to load projects for recovery: %w", err)
+	}
+
+	for _, project := range projects {
+		orchestrations, err := p.store.ListOrchestrations(project.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load orchestrations for project %s: %w", project.ID, err)
+		}
+
+		for _, orchestration := range orchestrations {
+			if orchestration.Status == Pending {
+				p.recoverPendingOrchestration(orchestration)
+				continue
+			}
+			if orchestration.Status != Processing {
+				continue
+			}
+
+			if err := p.recoverOrchestration(orchestration); err != nil {
+				p.Logger.Error().
+					Str("OrchestrationID", orchestration.ID).
+					Err(err).
+					Msg("Failed to recover orchestration")
+
+				p.failUnrecoverableOrchestration(orchestration, err)
+				continue
+			}
+
+			p.Logger.Info().
+				Str("OrchestrationID", orchestration.ID).
+				Msg("Recovered orchestration")
+		}
+	}
+
+	return nil
+}
+
+func (p *ControlPlane) recoverPendingOrchestration(orchestration *Orchestration) {
+	if orchestration.Plan == nil || orchestration.taskZero == nil {
+		p.failUnrecoverableOrchestration(orchestration, fmt.Errorf("orchestration %s was never planned", orchestration.ID))
+		return
+	}
+
+	p.Logger.Info().
+		Str("OrchestrationID", orchestration.ID).
+		Msg("Executing pending orchestration")
+	p.ExecuteOrchestration(orchestration)
+}
+
+func (p *ControlPlane) recoverOrchestration(orchestration *Orchestration) error {
+	if orchestration.Plan == nil {
+		return fmt.Errorf("orchestration %s has no plan", orchestration.ID)
+	}
+
+	log, err := p.LogManager.RecoverLog(orchestration)
+	if err != nil {
+		return err
+	}
+
+	if err := p.createAndStartWorkers(orchestration.ID, orchestration.Plan); err != nil {
+		return err
+	}
+
+	// The control plane stopped before the orchestration's task zero made it to the Log
+	if log.GetCurrentOffset() == 0 {
+		initialEntry := LogEntry{
+			Type:       "task_output",
+			ID:         TaskZero,
+			Value:      orchestration.taskZero,
+			ProducerID: "control-panel",
+		}
+		if err := log.Append(initialEntry); err != nil {
+			return fmt.Errorf("error appending initial entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (p *ControlPlane) failUnrecoverableOrchestration(orchestration *Orchestration, reason error) {
+	marshaledErr, _ := json.Marshal(fmt.Sprintf("Orchestration could not be recovered after a restart: %s", reason.Error()))
+	if err := p.LogManager.FinalizeOrchestration(orchestration.ID, Failed, marshaledErr, nil); err != nil {
+		p.Logger.Error().
+			Str("OrchestrationID", orchestration.ID).
+			Err(err).
+			Msg("Failed to finalize unrecoverable orchestration")
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecoverOrchestrationsDoesNotRerunCompletedTasks(t *testing.T) {
+	webhookCalls := make(chan map[string]any, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		webhookCalls <- payload
+	}))
+	defer webhook.Close()
+
+	plane, store := newTestPlane(t, nil)
+	_ = store.SaveProject(&Project{ID: "p1", APIKey: "key", Webhook: webhook.URL})
+
+	orchestration := &Orchestration{
+		ID:        "o1",
+		ProjectID: "p1",
+		Status:    Processing,
+		Plan: &ServiceCallingPlan{
+			ProjectID: "p1",
+			Tasks: []*SubTask{
+				{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+			},
+		},
+		taskZero: json.RawMessage(`{"message":"hello"}`),
+	}
+	_ = store.SaveOrchestration(orchestration)
+
+	// The control plane stopped right after task1 completed
+	logsDir := t.TempDir()
+	segments, err := OpenSegmentLog(filepath.Join(logsDir, orchestration.ID))
+	if err != nil {
+		t.Fatalf("failed to open segment log: %v", err)
+	}
+	_ = segments.Append(LogEntry{Offset: 0, Type: "task_output", ID: TaskZero, Value: orchestration.taskZero})
+	_ = segments.Append(LogEntry{Offset: 1, Type: "task_output", ID: "task1", Value: json.RawMessage(`{"echo":"hello"}`)})
+	_ = segments.Close()
+
+	ctx := startTestPlane(t, plane, logsDir)
+	if err := plane.MigrateProjects(); err != nil {
+		t.Fatalf("failed to migrate projects: %v", err)
+	}
+	plane.StartWebhookDelivery(ctx)
+	if err := plane.RecoverOrchestrations(); err != nil {
+		t.Fatalf("failed to recover orchestrations: %v", err)
+	}
+
+	select {
+	case payload := <-webhookCalls:
+		if payload["orchestrationId"] != orchestration.ID {
+			t.Errorf("unexpected webhook payload: %+v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("recovered orchestration was never finalized")
+	}
+
+	if _, queued := plane.WebSocketManager.messageQueues["s1"]; queued {
+		t.Errorf("completed task1 was sent to its service again")
+	}
+
+	recovered, err := store.GetOrchestration(orchestration.ID)
+	if err != nil || recovered.Status != Completed {
+		t.Errorf("got orchestration %+v, err %v, want completed", recovered, err)
+	}
+}
+
+func TestRecoverOrchestrationsExecutesPendingOrchestrations(t *testing.T) {
+	plane, store := newTestPlane(t, nil, echoService())
+	_ = store.SaveProject(&Project{ID: "p1"})
+
+	// The control plane stopped after planning o1 but before executing it, o2 was never planned
+	planned := echoOrchestration("o1", "hi")
+	planned.Plan = &ServiceCallingPlan{ProjectID: "p1", Tasks: []*SubTask{
+		{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+	}}
+	planned.taskZero = json.RawMessage(`{"message":"hi"}`)
+	_ = store.SaveOrchestration(planned)
+	_ = store.SaveOrchestration(echoOrchestration("o2", "hi"))
+
+	startTestPlane(t, plane, t.TempDir())
+	if err := plane.RecoverOrchestrations(); err != nil {
+		t.Fatalf("failed to recover orchestrations: %v", err)
+	}
+
+	if task := waitForQueuedTask(t, plane.WebSocketManager, "s1", 0); string(task.Input) != `{"message":"hi"}` {
+		t.Errorf("unexpected task input %s", task.Input)
+	}
+	if executed, _ := store.GetOrchestration("o1"); executed.Status != Processing {
+		t.Errorf("got o1 %s, want it processing", executed.Status.String())
+	}
+	if unplanned, _ := store.GetOrchestration("o2"); unplanned.Status != Failed {
+		t.Errorf("got o2 %s, want it failed", unplanned.Status.String())
+	}
+}
+
+func TestTaskWorkersMarkTheirOwnTaskCompleted(t *testing.T) {
+	plane, _ := newTestPlane(t, nil, echoService())
+	startTestPlane(t, plane, t.TempDir())
+
+	orchestration := echoOrchestration("o1", "hi")
+	orchestration.Plan = &ServiceCallingPlan{ProjectID: "p1", Tasks: []*SubTask{
+		{ID: "task1", Service: "s1", Input: map[string]Source{"message": "$task0.message"}},
+		{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+	}}
+	orchestration.taskZero = json.RawMessage(`{"message":"hi"}`)
+	plane.ExecuteOrchestration(orchestration)
+
+	// Consuming task0's output does not complete anything, task1 is only completed once it has an output
+	task1 := waitForQueuedTask(t, plane.WebSocketManager, "s1", 0)
+	if completed := plane.LogManager.CompletedTasks("o1"); len(completed) != 0 {
+		t.Errorf("expected no completed tasks before task1 replies, got %v", completed)
+	}
+
+	plane.WebSocketManager.callbacksMu.RLock()
+	pending := plane.WebSocketManager.taskCallbacks[task1.ExecutionID]
+	plane.WebSocketManager.callbacksMu.RUnlock()
+	pending.callback(json.RawMessage(`{"echo":"hi"}`), nil)
+
+	waitForQueuedTask(t, plane.WebSocketManager, "s1", 1)
+	if completed := plane.LogManager.CompletedTasks("o1"); len(completed) != 1 || !completed["task1"] {
+		t.Errorf("expected only task1 to be completed, got %v", completed)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrInvalidServiceSchema = errors.New("invalid service schema")
+
+	specTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+	compiledPatterns = newPatternCache()
+)
+
+// patternCache keeps the schemas' compiled patterns so they are compiled when a service registers rather
+// than for every value validated. Once full the least recently used pattern is evicted.
+type patternCache struct {
+	entries map[string]*patternCacheEntry
+	uses    uint64
+	mu      sync.Mutex
+}
+
+type patternCacheEntry struct {
+	compiled *regexp.Regexp
+	lastUsed uint64
+}
+
+func newPatternCache() *patternCache {
+	return &patternCache{entries: make(map[string]*patternCacheEntry)}
+}
+
+// compilePattern returns the compiled pattern, compiling and caching it when it isn't cached.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return compiledPatterns.compile(pattern)
+}
+
+func (c *patternCache) compile(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.uses++
+	if entry, ok := c.entries[pattern]; ok {
+		entry.lastUsed = c.uses
+		return entry.compiled, nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.entries) >= PatternCacheMaxEntries {
+		c.evict()
+	}
+	c.entries[pattern] = &patternCacheEntry{compiled: compiled, lastUsed: c.uses}
+	return compiled, nil
+}
+
+// evict drops the least recently used pattern. Callers must hold mu.
+func (c *patternCache) evict() {
+	var oldest string
+	for pattern, entry := range c.entries {
+		if oldest == "" || entry.lastUsed < c.entries[oldest].lastUsed {
+			oldest = pattern
+		}
+	}
+	delete(c.entries, oldest)
+}
+
+// UnmarshalJSON decodes a spec, accepting an object's properties as a JSON Schema "properties" map, as
+// the SDK's "fields" list of named specs, e.g. [{"name": "customerId", "type": "string"}], or both.
+func (s *Spec) UnmarshalJSON(data []byte) error {
+	type spec Spec
+	var decoded struct {
+		spec
+		Fields []json.RawMessage `json:"fields,omitempty"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*s = Spec(decoded.spec)
+	for i, data := range decoded.Fields {
+		var field struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(data, &field); err != nil {
+			return err
+		}
+		if field.Name == "" {
+			return fmt.Errorf("field %d has no name", i)
+		}
+		if _, exists := s.Properties[field.Name]; exists {
+			return fmt.Errorf("field %s is declared more than once", field.Name)
+		}
+
+		var property Spec
+		if err := json.Unmarshal(data, &property); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if s.Properties == nil {
+			s.Properties = make(Properties)
+		}
+		s.Properties[field.Name] = property
+	}
+	return nil
+}
+
+// validateServiceSchema checks the schema a service registers with is well-formed.
+func validateServiceSchema(schema ServiceSchema) error {
+	problems := append(schema.Input.problems("input"), schema.Output.problems("output")...)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidServiceSchema, errors.Join(problems...))
+}
+
+func (s Spec) problems(field string) []error {
+	var problems []error
+	report := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if s.Type != "" && !slices.Contains(specTypes, s.Type) {
+		report("unknown type %s", s.Type)
+	}
+	if len(s.Properties) > 0 && s.Type != "object" && s.Type != "" {
+		report("properties are only allowed for objects")
+	}
+	for _, required := range s.Required {
+		if len(s.Properties) > 0 && !s.IncludesProp(required) {
+			report("required field %s is not a property", required)
+		}
+	}
+	if s.Items != nil && s.Type != "array" {
+		report("items are only allowed for arrays")
+	}
+	if s.Pattern != "" {
+		if _, err := compilePattern(s.Pattern); err != nil {
+			report("invalid pattern: %v", err)
+		}
+	}
+	if (s.MinLength != nil && *s.MinLength < 0) || (s.MaxLength != nil && *s.MaxLength < 0) {
+		report("lengths cannot be negative")
+	}
+	if s.MinLength != nil && s.MaxLength != nil && *s.MinLength > *s.MaxLength {
+		report("minLength %d is greater than maxLength %d", *s.MinLength, *s.MaxLength)
+	}
+	if s.Minimum != nil && s.Maximum != nil && *s.Minimum > *s.Maximum {
+		report("minimum %v is greater than maximum %v", *s.Minimum, *s.Maximum)
+	}
+
+	for _, name := range sortedKeys(s.Properties) {
+		problems = append(problems, s.Properties[name].problems(field+"."+name)...)
+	}
+	if s.Items != nil {
+		problems = append(problems, s.Items.problems(field+"[]")...)
+	}
+	return problems
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSpecAcceptsFieldsAndProperties(t *testing.T) {
+	var schema ServiceSchema
+	err := json.Unmarshal([]byte(`{
+		"input": {
+			"type": "object",
+			"fields": [
+				{"name": "customerId", "type": "string", "format": "uuid", "description": "The customer"},
+				{"name": "tags", "type": "array", "items": {"type": "string", "enum": ["vip", "new"]}},
+				{"name": "address", "type": "object", "fields": [{"name": "city", "type": "string", "minLength": 1}]}
+			],
+			"required": ["customerId"]
+		},
+		"output": {
+			"type": "object",
+			"properties": {"balance": {"type": "number", "minimum": 0, "maximum": 99.5, "nullable": true}}
+		}
+	}`), &schema)
+	if err != nil {
+		t.Fatalf("failed to decode schema: %v", err)
+	}
+
+	if !schema.InputIncludes("customerId") || schema.Input.Properties["customerId"].Description != "The customer" {
+		t.Errorf("expected the customerId field as a property, got %+v", schema.Input.Properties)
+	}
+	if items := schema.Input.Properties["tags"].Items; items == nil || len(items.Enum) != 2 {
+		t.Errorf("expected the tags items enum, got %+v", items)
+	}
+	if city := schema.Input.Properties["address"].Properties["city"]; city.MinLength == nil || *city.MinLength != 1 {
+		t.Errorf("expected the nested city field, got %+v", city)
+	}
+	if balance := schema.Output.Properties["balance"]; balance.Minimum == nil || *balance.Minimum != 0 || *balance.Maximum != 99.5 || !balance.Nullable {
+		t.Errorf("expected the balance bounds, got %+v", balance)
+	}
+	if err := validateServiceSchema(schema); err != nil {
+		t.Errorf("expected a valid schema, got %v", err)
+	}
+
+	data, _ := json.Marshal(schema.Input)
+	if strings.Contains(string(data), "fields") || !strings.Contains(string(data), `"properties"`) {
+		t.Errorf("expected fields to be encoded as properties, got %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type": "object", "fields": [{"type": "string"}]}`), &Spec{}); err == nil {
+		t.Errorf("expected an error for a field without a name")
+	}
+}
+
+func TestValidateServiceSchemaRejectsMalformedSpecs(t *testing.T) {
+	schema := ServiceSchema{
+		Input: Spec{
+			Type:     "object",
+			Required: []string{"missing"},
+			Properties: Properties{
+				"name":  {Type: "string", Pattern: "(", MinLength: ptr(3), MaxLength: ptr(1)},
+				"count": {Type: "int", Minimum: ptr(5.0), Maximum: ptr(1.0)},
+			},
+		},
+		Output: Spec{Type: "string", Items: &Spec{Type: "string"}},
+	}
+
+	err := validateServiceSchema(schema)
+	if !errors.Is(err, ErrInvalidServiceSchema) {
+		t.Fatalf("expected ErrInvalidServiceSchema, got %v", err)
+	}
+	for _, want := range []string{
+		"input: required field missing is not a property",
+		"input.count: unknown type int",
+		"input.count: minimum 5 is greater than maximum 1",
+		"input.name: invalid pattern",
+		"input.name: minLength 3 is greater than maxLength 1",
+		"output: items are only allowed for arrays",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestPatternCacheEvictsLeastRecentlyUsedPatterns(t *testing.T) {
+	defer func(original int) { PatternCacheMaxEntries = original }(PatternCacheMaxEntries)
+	PatternCacheMaxEntries = 2
+
+	cache := newPatternCache()
+	for _, pattern := range []string{"^a$", "^b$", "^a$", "^c$"} {
+		if _, err := cache.compile(pattern); err != nil {
+			t.Fatalf("failed to compile %s: %v", pattern, err)
+		}
+	}
+
+	if len(cache.entries) != 2 {
+		t.Fatalf("expected the cache to hold 2 patterns, got %d", len(cache.entries))
+	}
+	for pattern, wantCached := range map[string]bool{"^a$": true, "^b$": false, "^c$": true} {
+		if _, cached := cache.entries[pattern]; cached != wantCached {
+			t.Errorf("pattern %s: got cached %v, want %v", pattern, cached, wantCached)
+		}
+	}
+
+	if _, err := cache.compile("("); err == nil || len(cache.entries) != 2 {
+		t.Errorf("expected an invalid pattern to fail without being cached, got err %v and %d patterns", err, len(cache.entries))
+	}
+}
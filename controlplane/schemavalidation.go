@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// SchemaViolation is a payload field that does not conform to a service's schema.
+type SchemaViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// SchemaValidationError reports every field of a task's input or output that does not conform to the
+// service's schema. Resending the same payload cannot succeed so it is never retried.
+type SchemaValidationError struct {
+	ServiceID  string
+	Payload    string
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	violations := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		violations[i] = violation.Field + ": " + violation.Message
+	}
+	return fmt.Sprintf("task %s does not conform to the schema of service %s: %s", e.Payload, e.ServiceID, strings.Join(violations, "; "))
+}
+
+// validatePayload checks a task's input or output against the spec, payload names the root field in the
+// reported violations. A spec without a type accepts any payload.
+func validatePayload(serviceID, payload string, spec Spec, document json.RawMessage) error {
+	if spec.Type == "" {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return &SchemaValidationError{
+			ServiceID:  serviceID,
+			Payload:    payload,
+			Violations: []SchemaViolation{{Field: payload, Message: "is not valid JSON"}},
+		}
+	}
+
+	if violations := spec.violations(payload, value); len(violations) > 0 {
+		return &SchemaValidationError{ServiceID: serviceID, Payload: payload, Violations: violations}
+	}
+	return nil
+}
+
+func (s Spec) violations(field string, value any) []SchemaViolation {
+	violation := func(format string, args ...any) []SchemaViolation {
+		return []SchemaViolation{{Field: field, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if value == nil && s.Nullable {
+		return nil
+	}
+	if actual := jsonType(value); !s.accepts(actual, value) {
+		return violation("expected %s, got %s", s.Type, actual)
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return sameJSONValue(allowed, value) }) {
+		allowed, _ := json.Marshal(s.Enum)
+		return violation("must be one of %s", allowed)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		var violations []SchemaViolation
+		for _, required := range s.Required {
+			if _, ok := v[required]; !ok {
+				violations = append(violations, SchemaViolation{Field: field + "." + required, Message: "is required"})
+			}
+		}
+		for _, name := range sortedKeys(s.Properties) {
+			if property, ok := v[name]; ok {
+				violations = append(violations, s.Properties[name].violations(field+"."+name, property)...)
+			}
+		}
+		return violations
+	case []any:
+		if s.Items == nil {
+			return nil
+		}
+		var violations []SchemaViolation
+		for i, item := range v {
+			violations = append(violations, s.Items.violations(fmt.Sprintf("%s[%d]", field, i), item)...)
+		}
+		return violations
+	case json.Number:
+		number, _ := v.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			return violation("must be at least %v, got %s", *s.Minimum, v)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			return violation("must be at most %v, got %s", *s.Maximum, v)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			return violation("must be at least %d characters long, got %d", *s.MinLength, length)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return violation("must be at most %d characters long, got %d", *s.MaxLength, length)
+		}
+		if s.Pattern != "" {
+			pattern, err := compilePattern(s.Pattern)
+			if err != nil {
+				return violation("cannot be checked against the invalid pattern %s: %v", s.Pattern, err)
+			}
+			if !pattern.MatchString(v) {
+				return violation("must match the pattern %s, got %q", s.Pattern, v)
+			}
+		}
+		if !matchesFormat(s.Format, v) {
+			return violation("must be a valid %s, got %q", s.Format, v)
+		}
+	}
+	return nil
+}
+
+// sameJSONValue compares an allowed enum value with a payload value, numbers are compared by value.
+func sameJSONValue(allowed, value any) bool {
+	if number, ok := value.(json.Number); ok {
+		actual, err := number.Float64()
+		expected, isNumber := allowed.(float64)
+		return err == nil && isNumber && actual == expected
+	}
+	return reflect.DeepEqual(allowed, value)
+}
+
+// accepts reports whether a value of the JSON type is allowed by the spec's type, unknown types accept anything.
+func (s Spec) accepts(actual string, value any) bool {
+	switch s.Type {
+	case "object", "array", "string", "boolean", "null":
+		return actual == s.Type
+	case "number":
+		return actual == "number"
+	case "integer":
+		if actual != "number" {
+			return false
+		}
+		number, err := value.(json.Number).Float64()
+		return err == nil && number == math.Trunc(number)
+	default:
+		return true
+	}
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// matchesFormat checks the string formats services commonly declare, other formats are not checked.
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		_, err := uuid.Parse(value)
+		return err == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidatePayload(t *testing.T) {
+	spec := Spec{
+		Type:     "object",
+		Required: []string{"id", "email"},
+		Properties: Properties{
+			"id":      {Type: "string", Format: "uuid"},
+			"email":   {Type: "string", Format: "email"},
+			"age":     {Type: "integer", Minimum: ptr(18.0), Maximum: ptr(120.0)},
+			"score":   {Type: "number"},
+			"active":  {Type: "boolean"},
+			"tags":    {Type: "array", Items: &Spec{Type: "string", MinLength: ptr(2), MaxLength: ptr(8), Pattern: "^[a-z]+$"}},
+			"plan":    {Type: "string", Enum: []any{"free", "pro"}},
+			"tier":    {Type: "integer", Enum: []any{1.0, 2.0}},
+			"note":    {Type: "string", Nullable: true, Description: "Free text"},
+			"address": {Type: "object", Required: []string{"city"}, Properties: Properties{"city": {Type: "string"}}},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		document string
+		want     []SchemaViolation
+	}{
+		{
+			name:     "valid",
+			document: `{"id":"0b0a3d2e-4c8f-4a8e-9a52-1f1b7d0d6c11","email":"a@example.com","age":30,"score":1.5,"active":true,"tags":["vip"],"plan":"pro","tier":2,"note":null,"address":{"city":"London"},"extra":1}`,
+		},
+		{
+			name:     "missing required fields",
+			document: `{"address":{}}`,
+			want: []SchemaViolation{
+				{Field: "input.id", Message: "is required"},
+				{Field: "input.email", Message: "is required"},
+				{Field: "input.address.city", Message: "is required"},
+			},
+		},
+		{
+			name:     "wrong types, formats and ranges",
+			document: `{"id":"x","email":"a@example.com","age":12.5,"score":"high","active":null,"tags":{},"address":{"city":7}}`,
+			want: []SchemaViolation{
+				{Field: "input.active", Message: "expected boolean, got null"},
+				{Field: "input.address.city", Message: "expected string, got number"},
+				{Field: "input.age", Message: "expected integer, got number"},
+				{Field: "input.id", Message: `must be a valid uuid, got "x"`},
+				{Field: "input.score", Message: "expected number, got string"},
+				{Field: "input.tags", Message: "expected array, got object"},
+			},
+		},
+		{
+			name:     "enums, lengths, patterns and array items",
+			document: `{"id":"0b0a3d2e-4c8f-4a8e-9a52-1f1b7d0d6c11","email":"a@example.com","plan":"gold","tier":3,"tags":["a","vip","VIP",1]}`,
+			want: []SchemaViolation{
+				{Field: "input.plan", Message: `must be one of ["free","pro"]`},
+				{Field: "input.tags[0]", Message: "must be at least 2 characters long, got 1"},
+				{Field: "input.tags[2]", Message: `must match the pattern ^[a-z]+$, got "VIP"`},
+				{Field: "input.tags[3]", Message: "expected string, got number"},
+				{Field: "input.tier", Message: "must be one of [1,2]"},
+			},
+		},
+		{
+			name:     "out of range",
+			document: `{"id":"0b0a3d2e-4c8f-4a8e-9a52-1f1b7d0d6c11","email":"a@example.com","age":121}`,
+			want:     []SchemaViolation{{Field: "input.age", Message: "must be at most 120, got 121"}},
+		},
+		{
+			name:     "not an object",
+			document: `["a"]`,
+			want:     []SchemaViolation{{Field: "input", Message: "expected object, got array"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePayload("s1", "input", spec, json.RawMessage(tc.document))
+			if len(tc.want) == 0 {
+				if err != nil {
+					t.Fatalf("expected a valid payload, got %v", err)
+				}
+				return
+			}
+
+			var schemaErr *SchemaValidationError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("expected a schema validation error, got %v", err)
+			}
+			if len(schemaErr.Violations) != len(tc.want) {
+				t.Fatalf("got violations %+v, want %+v", schemaErr.Violations, tc.want)
+			}
+			for i, violation := range schemaErr.Violations {
+				if violation != tc.want[i] {
+					t.Errorf("got violation %+v, want %+v", violation, tc.want[i])
+				}
+			}
+			if isRetryableError(err) {
+				t.Errorf("expected schema validation errors not to be retried")
+			}
+		})
+	}
+
+	if err := validatePayload("s1", "output", Spec{}, json.RawMessage(`"anything"`)); err != nil {
+		t.Errorf("expected a service without a schema to accept any payload, got %v", err)
+	}
+}
+
+func TestValidatePayloadReportsInvalidPatterns(t *testing.T) {
+	// Schemas saved before patterns were checked at registration may still hold an invalid one
+	spec := Spec{Type: "object", Properties: Properties{"code": {Type: "string", Pattern: "[a-z"}}}
+
+	err := validatePayload("s1", "input", spec, json.RawMessage(`{"code":"abc"}`))
+	var schemaErr *SchemaValidationError
+	if !errors.As(err, &schemaErr) || len(schemaErr.Violations) != 1 || schemaErr.Violations[0].Field != "input.code" {
+		t.Errorf("expected the invalid pattern to be reported, got %v", err)
+	}
+}
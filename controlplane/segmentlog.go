@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	segmentLogExt   = ".log"
+	segmentIndexExt = ".index"
+	indexEntryWidth = 8
+)
+
+// SegmentLog persists an orchestration's LogEntry values to append-only segment files.
+// Each segment is a pair of files named after the segment's base offset: a `.log` file with
+// one JSON encoded entry per line and an `.index` file mapping each offset to its position in the `.log` file.
+type SegmentLog struct {
+	dir        string
+	segments   []*segment
+	nextOffset uint64
+	mu         sync.Mutex
+}
+
+type segment struct {
+	baseOffset uint64
+	nextOffset uint64
+	size       int64
+	log        *os.File
+	index      *os.File
+}
+
+// OpenSegmentLog opens the segment log stored in dir, creating it if needed. Any partially
+// written entry left behind by a crash is truncated away.
+func OpenSegmentLog(dir string) (*SegmentLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %w", dir, err)
+	}
+
+	baseOffsets, err := segmentBaseOffsets(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sl := &SegmentLog{dir: dir}
+	for _, base := range baseOffsets {
+		seg, err := openSegment(dir, base)
+		if err != nil {
+			_ = sl.Close()
+			return nil, err
+		}
+		sl.segments = append(sl.segments, seg)
+		sl.nextOffset = seg.nextOffset
+	}
+
+	if len(sl.segments) == 0 {
+		if err := sl.roll(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sl, nil
+}
+
+// Append writes the entry to the active segment, its offset has to be the next offset in the log.
+func (sl *SegmentLog) Append(entry LogEntry) error {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	if entry.Offset != sl.nextOffset {
+		return fmt.Errorf("cannot append entry with offset %d, next offset is %d", entry.Offset, sl.nextOffset)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	active := sl.segments[len(sl.segments)-1]
+	if active.size > 0 && active.size+int64(len(data)) > LogSegmentMaxBytes {
+		if err := sl.roll(); err != nil {
+			return err
+		}
+		active = sl.segments[len(sl.segments)-1]
+	}
+
+	if err := active.append(data); err != nil {
+		return err
+	}
+	sl.nextOffset = active.nextOffset
+
+	return nil
+}
+
+// ReadFrom returns every persisted entry starting at offset.
+func (sl *SegmentLog) ReadFrom(offset uint64) ([]LogEntry, error) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	var out []LogEntry
+	for _, seg := range sl.segments {
+		if offset >= seg.nextOffset {
+			continue
+		}
+
+		from := max(offset, seg.baseOffset)
+		entries, err := seg.readFrom(from)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, entries...)
+	}
+	return out, nil
+}
+
+func (sl *SegmentLog) NextOffset() uint64 {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	return sl.nextOffset
+}
+
+func (sl *SegmentLog) Close() error {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	var errs []error
+	for _, seg := range sl.segments {
+		errs = append(errs, seg.close())
+	}
+	sl.segments = nil
+	return errors.Join(errs...)
+}
+
+func (sl *SegmentLog) roll() error {
+	seg, err := openSegment(sl.dir, sl.nextOffset)
+	if err != nil {
+		return err
+	}
+	sl.segments = append(sl.segments, seg)
+	return nil
+}
+
+func openSegment(dir string, baseOffset uint64) (*segment, error) {
+	name := fmt.Sprintf("%020d", baseOffset)
+
+	logFile, err := os.OpenFile(filepath.Join(dir, name+segmentLogExt), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log segment %s: %w", name, err)
+	}
+
+	indexFile, err := os.OpenFile(filepath.Join(dir, name+segmentIndexExt), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		_ = logFile.Close()
+		return nil, fmt.Errorf("failed to open log segment index %s: %w", name, err)
+	}
+
+	seg := &segment{baseOffset: baseOffset, log: logFile, index: indexFile}
+	if err := seg.recover(); err != nil {
+		_ = seg.close()
+		return nil, fmt.Errorf("failed to recover log segment %s: %w", name, err)
+	}
+
+	return seg, nil
+}
+
+// recover trims the segment back to its last fully written entry.
+func (s *segment) recover() error {
+	indexInfo, err := s.index.Stat()
+	if err != nil {
+		return err
+	}
+
+	count := indexInfo.Size() / indexEntryWidth
+	if err := s.index.Truncate(count * indexEntryWidth); err != nil {
+		return err
+	}
+
+	var end int64
+	for count > 0 {
+		position, err := s.position(uint64(count - 1))
+		if err != nil {
+			return err
+		}
+		line, err := bufio.NewReader(io.NewSectionReader(s.log, position, 1<<62)).ReadBytes('\n')
+		if err == nil && json.Valid(line) {
+			end = position + int64(len(line))
+			break
+		}
+		// The index points at an entry that never made it to disk, drop it.
+		count--
+		if err := s.index.Truncate(count * indexEntryWidth); err != nil {
+			return err
+		}
+	}
+
+	if err := s.log.Truncate(end); err != nil {
+		return err
+	}
+
+	s.size = end
+	s.nextOffset = s.baseOffset + uint64(count)
+	return nil
+}
+
+func (s *segment) append(data []byte) error {
+	if _, err := s.log.WriteAt(data, s.size); err != nil {
+		return fmt.Errorf("failed to write log entry: %w", err)
+	}
+	if err := s.log.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log segment: %w", err)
+	}
+
+	var position [indexEntryWidth]byte
+	binary.BigEndian.PutUint64(position[:], uint64(s.size))
+	relative := int64(s.nextOffset - s.baseOffset)
+	if _, err := s.index.WriteAt(position[:], relative*indexEntryWidth); err != nil {
+		return fmt.Errorf("failed to write log index entry: %w", err)
+	}
+	if err := s.index.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log segment index: %w", err)
+	}
+
+	s.size += int64(len(data))
+	s.nextOffset++
+	return nil
+}
+
+func (s *segment) readFrom(offset uint64) ([]LogEntry, error) {
+	position, err := s.position(offset - s.baseOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(s.log, position, s.size-position))
+	out := make([]LogEntry, 0, s.nextOffset-offset)
+	for i := offset; i < s.nextOffset; i++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log entry %d: %w", i, err)
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal log entry %d: %w", i, err)
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+func (s *segment) position(relativeOffset uint64) (int64, error) {
+	var position [indexEntryWidth]byte
+	if _, err := s.index.ReadAt(position[:], int64(relativeOffset)*indexEntryWidth); err != nil {
+		return 0, fmt.Errorf("failed to read log index entry %d: %w", relativeOffset, err)
+	}
+	return int64(binary.BigEndian.Uint64(position[:])), nil
+}
+
+func (s *segment) close() error {
+	return errors.Join(s.log.Close(), s.index.Close())
+}
+
+func segmentBaseOffsets(dir string) ([]uint64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log directory %s: %w", dir, err)
+	}
+
+	var out []uint64
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, segmentLogExt) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, segmentLogExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, base)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out, nil
+}
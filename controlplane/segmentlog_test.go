@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSegmentLogRollsAndReopens(t *testing.T) {
+	defer func(original int64) { LogSegmentMaxBytes = original }(LogSegmentMaxBytes)
+	LogSegmentMaxBytes = 256
+
+	dir := t.TempDir()
+	sl, err := OpenSegmentLog(dir)
+	if err != nil {
+		t.Fatalf("failed to open segment log: %v", err)
+	}
+
+	for i := uint64(0); i < 10; i++ {
+		entry := LogEntry{
+			Offset: i,
+			Type:   "task_output",
+			ID:     fmt.Sprintf("task%d", i),
+			Value:  json.RawMessage(`{"value":"some output"}`),
+		}
+		if err := sl.Append(entry); err != nil {
+			t.Fatalf("failed to append entry %d: %v", i, err)
+		}
+	}
+
+	if err := sl.Append(LogEntry{Offset: 42}); err == nil {
+		t.Errorf("expected an error appending an out of sequence offset")
+	}
+
+	if len(sl.segments) < 2 {
+		t.Errorf("expected the log to roll into several segments, got %d", len(sl.segments))
+	}
+	if err := sl.Close(); err != nil {
+		t.Fatalf("failed to close segment log: %v", err)
+	}
+
+	sl, err = OpenSegmentLog(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen segment log: %v", err)
+	}
+	defer sl.Close()
+
+	if sl.NextOffset() != 10 {
+		t.Errorf("got next offset %d, want 10", sl.NextOffset())
+	}
+
+	entries, err := sl.ReadFrom(3)
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	if len(entries) != 7 {
+		t.Fatalf("got %d entries, want 7", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.Offset != uint64(i+3) || entry.ID != fmt.Sprintf("task%d", i+3) {
+			t.Errorf("unexpected entry at %d: %+v", i, entry)
+		}
+	}
+}
+
+func TestSegmentLogTruncatesPartialWrites(t *testing.T) {
+	dir := t.TempDir()
+	sl, err := OpenSegmentLog(dir)
+	if err != nil {
+		t.Fatalf("failed to open segment log: %v", err)
+	}
+	for i := uint64(0); i < 2; i++ {
+		if err := sl.Append(LogEntry{Offset: i, Type: "task_output", ID: "task0"}); err != nil {
+			t.Fatalf("failed to append entry %d: %v", i, err)
+		}
+	}
+	if err := sl.Close(); err != nil {
+		t.Fatalf("failed to close segment log: %v", err)
+	}
+
+	// Simulate a crash halfway through writing a third entry.
+	segmentFile := filepath.Join(dir, fmt.Sprintf("%020d%s", 0, segmentLogExt))
+	f, err := os.OpenFile(segmentFile, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("failed to open segment file: %v", err)
+	}
+	if _, err := f.WriteString(`{"offset":2,"type":"task_`); err != nil {
+		t.Fatalf("failed to write partial entry: %v", err)
+	}
+	_ = f.Close()
+
+	sl, err = OpenSegmentLog(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen segment log: %v", err)
+	}
+	defer sl.Close()
+
+	if sl.NextOffset() != 2 {
+		t.Fatalf("got next offset %d, want 2", sl.NextOffset())
+	}
+	if err := sl.Append(LogEntry{Offset: 2, Type: "task_output", ID: "task1"}); err != nil {
+		t.Fatalf("failed to append after recovery: %v", err)
+	}
+
+	entries, err := sl.ReadFrom(0)
+	if err != nil {
+		t.Fatalf("failed to read entries: %v", err)
+	}
+	if len(entries) != 3 || entries[2].ID != "task1" {
+		t.Errorf("unexpected entries after recovery: %+v", entries)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	MemoryStorage = "memory"
+	BoltStorage   = "bolt"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Store persists the projects, services and orchestrations managed by the control plane.
+type Store interface {
+	SaveProject(project *Project) error
+	GetProject(id string) (*Project, error)
+	ListProjects() ([]*Project, error)
+	// DeleteProject removes the project along with its API keys, services and their versions, workflows, orchestrations,
+	// their states and delivery attempts, and its webhook deliveries.
+	DeleteProject(id string) error
+
+	SaveAPIKey(key *APIKey) error
+	ListAPIKeys(projectID string) ([]*APIKey, error)
+
+	SaveAdminCredential(credential *AdminCredential) error
+	GetAdminCredential() (*AdminCredential, error)
+
+	// SaveService saves the service as its latest version and keeps it in the service's version history.
+	SaveService(service *ServiceInfo) error
+	GetService(projectID, serviceID string) (*ServiceInfo, error)
+	ListServices(projectID string) ([]*ServiceInfo, error)
+	GetServiceVersion(projectID, serviceID string, version int64) (*ServiceInfo, error)
+	// ListServiceVersions returns the service's version history, oldest first.
+	ListServiceVersions(projectID, serviceID string) ([]*ServiceInfo, error)
+
+	SaveWorkflow(workflow *Workflow) error
+	GetWorkflow(projectID, name string) (*Workflow, error)
+	ListWorkflows(projectID string) ([]*Workflow, error)
+	DeleteWorkflow(projectID, name string) error
+
+	SaveOrchestration(orchestration *Orchestration) error
+	GetOrchestration(id string) (*Orchestration, error)
+	ListOrchestrations(projectID string) ([]*Orchestration, error)
+
+	SaveOrchestrationState(state *OrchestrationState) error
+	GetOrchestrationState(id string) (*OrchestrationState, error)
+
+	SaveWorkerState(orchestrationID, workerID string, state *LogState) error
+	GetWorkerState(orchestrationID, workerID string) (*LogState, error)
+
+	SaveWebhookDelivery(delivery *WebhookDelivery) error
+	GetWebhookDelivery(id string) (*WebhookDelivery, error)
+	// ListWebhookDeliveries returns the project's pending and dead-lettered deliveries, or every
+	// project's when the projectID is empty.
+	ListWebhookDeliveries(projectID string) ([]*WebhookDelivery, error)
+	// ListDueWebhookDeliveries returns every project's pending deliveries due by now, earliest first, and
+	// when the next pending delivery is due, or the zero time if none is. Dead letters are never loaded.
+	ListDueWebhookDeliveries(now time.Time) ([]*WebhookDelivery, time.Time, error)
+	DeleteWebhookDelivery(id string) error
+
+	SaveDeliveryAttempt(attempt *DeliveryAttempt) error
+	ListDeliveryAttempts(orchestrationID string) ([]*DeliveryAttempt, error)
+
+	Close() error
+}
+
+// NewStore creates the Store selected by the config, file backed stores are kept in the config's data directory.
+func NewStore(cfg Config) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.StorageType)) {
+	case MemoryStorage:
+		return NewMemoryStore(), nil
+	case BoltStorage:
+		return NewBoltStore(filepath.Join(cfg.DataDir, "orra.db"))
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", cfg.StorageType)
+	}
+}
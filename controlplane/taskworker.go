@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -18,12 +19,14 @@ var (
 	maxDelay   = 60 * time.Second
 )
 
-func NewTaskWorker(serviceID string, taskID string, dependencies DependencyKeys, logManager *LogManager) LogWorker {
+func NewTaskWorker(serviceID string, serviceVersion int64, taskID string, dependencies DependencyKeys, input map[string]Source, logManager *LogManager) LogWorker {
 	return &TaskWorker{
-		ServiceID:    serviceID,
-		TaskID:       taskID,
-		Dependencies: dependencies,
-		LogManager:   logManager,
+		ServiceID:      serviceID,
+		ServiceVersion: serviceVersion,
+		TaskID:         taskID,
+		Dependencies:   dependencies,
+		Input:          input,
+		LogManager:     logManager,
 		logState: &LogState{
 			LastOffset:      0,
 			Processed:       make(map[string]bool),
@@ -39,6 +42,22 @@ func (w *TaskWorker) Start(ctx context.Context, orchestrationID string) {
 		return
 	}
 
+	if w.completedIn(logStream) {
+		w.LogManager.Logger.Info().Msgf("TaskWorker for task %s in orchestration %s has already completed", w.TaskID, orchestrationID)
+		return
+	}
+
+	// A recovered worker may have received all its dependencies before the control plane stopped
+	if w.loadState(orchestrationID) && containsAll(w.logState.DependencyState, w.Dependencies) {
+		if err := w.executeAndRecord(ctx, orchestrationID, ""); err != nil {
+			w.LogManager.Logger.
+				Error().
+				Err(err).
+				Msgf("Task worker %s failed to resume task for orchestration: %s", w.TaskID, orchestrationID)
+			return
+		}
+	}
+
 	// Channel to receive new log entries
 	entriesChan := make(chan LogEntry, 100)
 
@@ -76,7 +95,11 @@ func (w *TaskWorker) PollLog(ctx context.Context, orchestrationID string, logStr
 		case <-ticker.C:
 			var processableEntries []LogEntry
 
-			entries := logStream.ReadFrom(w.logState.LastOffset)
+			w.stateMu.Lock()
+			lastOffset := w.logState.LastOffset
+			w.stateMu.Unlock()
+
+			entries := logStream.ReadFrom(lastOffset)
 			for _, entry := range entries {
 				if !w.shouldProcess(entry) {
 					continue
@@ -85,7 +108,9 @@ func (w *TaskWorker) PollLog(ctx context.Context, orchestrationID string, logStr
 				processableEntries = append(processableEntries, entry)
 				select {
 				case entriesChan <- entry:
+					w.stateMu.Lock()
 					w.logState.LastOffset = entry.Offset + 1
+					w.stateMu.Unlock()
 				case <-ctx.Done():
 					return
 				}
@@ -101,6 +126,9 @@ func (w *TaskWorker) PollLog(ctx context.Context, orchestrationID string, logStr
 }
 
 func (w *TaskWorker) shouldProcess(entry LogEntry) bool {
+	w.stateMu.Lock()
+	defer w.stateMu.Unlock()
+
 	_, isDependency := w.Dependencies[entry.ID]
 	processed := w.logState.Processed[entry.ID]
 	return entry.Type == "task_output" && isDependency && !processed
@@ -108,12 +136,21 @@ func (w *TaskWorker) shouldProcess(entry LogEntry) bool {
 
 func (w *TaskWorker) processEntry(ctx context.Context, entry LogEntry, orchestrationID string) error {
 	// Store the entry's output in our dependency state
+	w.stateMu.Lock()
 	w.logState.DependencyState[entry.ID] = entry.Value
+	w.stateMu.Unlock()
+
+	w.saveState(orchestrationID, entry.Offset+1)
 
 	if !containsAll(w.logState.DependencyState, w.Dependencies) {
 		return nil
 	}
 
+	return w.executeAndRecord(ctx, orchestrationID, entry.ID)
+}
+
+// executeAndRecord runs the task once all its dependencies are available and appends its output to the Log.
+func (w *TaskWorker) executeAndRecord(ctx context.Context, orchestrationID string, entryID string) error {
 	// Execute our task
 	output, err := w.executeTaskWithRetry(ctx, orchestrationID)
 	if err != nil {
@@ -122,11 +159,10 @@ func (w *TaskWorker) processEntry(ctx context.Context, entry LogEntry, orchestra
 	}
 
 	// Mark this entry as processed
-	w.logState.Processed[entry.ID] = true
-
-	if _, err := w.LogManager.MarkTaskCompleted(orchestrationID, entry.ID); err != nil {
-		w.LogManager.Logger.Error().Err(err).Msgf("Cannot mark task %s completed for orchestration %s", w.TaskID, orchestrationID)
-		return w.LogManager.AppendFailureToLog(orchestrationID, w.TaskID, w.ServiceID, err.Error())
+	if entryID != "" {
+		w.stateMu.Lock()
+		w.logState.Processed[entryID] = true
+		w.stateMu.Unlock()
 	}
 
 	// Create a new log entry for our task's output
@@ -148,9 +184,27 @@ func (w *TaskWorker) processEntry(ctx context.Context, entry LogEntry, orchestra
 			fmt.Errorf("failed to append task output to log: %w", err).Error())
 	}
 
+	// The worker's own task is marked completed once its output is in the Log, not the dependency it
+	// consumed, recovery and inspection rely on CompletedTasks only listing tasks that produced an output.
+	if _, err := w.LogManager.MarkTaskCompleted(orchestrationID, w.TaskID); err != nil {
+		w.LogManager.Logger.Error().Err(err).Msgf("Cannot mark task %s completed for orchestration %s", w.TaskID, orchestrationID)
+		return w.LogManager.AppendFailureToLog(orchestrationID, w.TaskID, w.ServiceID, err.Error())
+	}
+
+	w.LogManager.NotifyTaskEvent(EventTaskCompleted, orchestrationID, w.TaskID, w.ServiceID, output)
 	return nil
 }
 
+// completedIn checks whether the task's output was already appended to the Log, e.g. before a restart.
+func (w *TaskWorker) completedIn(logStream *Log) bool {
+	for _, entry := range logStream.ReadFrom(0) {
+		if entry.Type == "task_output" && entry.ID == w.TaskID {
+			return true
+		}
+	}
+	return false
+}
+
 func (w *TaskWorker) executeTaskWithRetry(ctx context.Context, orchestrationID string) (json.RawMessage, error) {
 	var result json.RawMessage
 	var err error
@@ -184,9 +238,18 @@ func (w *TaskWorker) executeTaskWithRetry(ctx context.Context, orchestrationID s
 }
 
 func (w *TaskWorker) executeTask(ctx context.Context, orchestrationID string) (json.RawMessage, error) {
-	input, err := mergeValueMapsToJson(w.logState.DependencyState)
+	projectID := w.LogManager.GetOrchestrationProjectID(orchestrationID)
+	schema, err := w.LogManager.controlPlane.GetServiceSchema(projectID, w.ServiceID, w.ServiceVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := resolveTaskInput(w.Input, w.logState.DependencyState, schema.Input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal input for task %s: %w", w.TaskID, err)
+		return nil, fmt.Errorf("failed to resolve input for task %s: %w", w.TaskID, err)
+	}
+	if err := validatePayload(w.ServiceID, "input", schema.Input, input); err != nil {
+		return nil, err
 	}
 
 	// Generate a unique execution ID
@@ -198,14 +261,14 @@ func (w *TaskWorker) executeTask(ctx context.Context, orchestrationID string) (j
 		Input:           input,
 		ServiceID:       w.ServiceID,
 		OrchestrationID: orchestrationID,
-		ProjectID:       w.LogManager.GetOrchestrationProjectID(orchestrationID),
+		ProjectID:       projectID,
 		Status:          Processing,
 	}
 
 	resultChan := make(chan json.RawMessage, 1)
 	errChan := make(chan error, 1)
 
-	w.LogManager.controlPlane.WebSocketManager.RegisterTaskCallback(executionID, func(result json.RawMessage, err error) {
+	w.LogManager.controlPlane.WebSocketManager.RegisterTaskCallback(executionID, w.ServiceID, schema.Output, func(result json.RawMessage, err error) {
 
 		fields := map[string]any{
 			"executionID": executionID,
@@ -241,47 +304,48 @@ func (w *TaskWorker) executeTask(ctx context.Context, orchestrationID string) (j
 	}
 }
 
-func (w *TaskWorker) saveState(orchestrationID string) {
+// saveState persists the worker's state, committedOffset is the offset after the last entry the worker has
+// fully processed which can be ahead of the polling offset.
+func (w *TaskWorker) saveState(orchestrationID string, committedOffset uint64) {
 	w.stateMu.Lock()
-	defer w.stateMu.Unlock()
+	state := w.logState.clone()
+	w.stateMu.Unlock()
 
-	stateKey := fmt.Sprintf("worker_state_%s_%s", orchestrationID, w.ServiceID)
-	_, err := json.Marshal(w.logState)
-	if err != nil {
-		w.LogManager.Logger.Error().Msgf("Failed to marshal worker state: %v", err)
+	state.LastOffset = committedOffset
+	if err := w.LogManager.controlPlane.store.SaveWorkerState(orchestrationID, w.TaskID, state); err != nil {
+		w.LogManager.Logger.Error().Err(err).Msgf("Failed to save worker state for task %s in orchestration %s", w.TaskID, orchestrationID)
 		return
 	}
 
-	// Here you would typically save this to a persistent store
-	// For this example, we'll just log it
-	w.LogManager.Logger.Debug().Msgf("Saved worker state: %s", stateKey)
+	w.LogManager.Logger.Debug().Msgf("Saved worker state for task %s in orchestration %s", w.TaskID, orchestrationID)
 }
 
-func (w *TaskWorker) loadState(orchestrationID string) {
-	w.stateMu.Lock()
-	defer w.stateMu.Unlock()
+// loadState restores any previously saved worker state, it reports whether a state was found.
+func (w *TaskWorker) loadState(orchestrationID string) bool {
+	state, err := w.LogManager.controlPlane.store.GetWorkerState(orchestrationID, w.TaskID)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			w.LogManager.Logger.Error().Err(err).Msgf("Failed to load worker state for task %s in orchestration %s", w.TaskID, orchestrationID)
+		}
+		return false
+	}
 
-	stateKey := fmt.Sprintf("worker_state_%s_%s", orchestrationID, w.ServiceID)
+	if state.Processed == nil {
+		state.Processed = make(map[string]bool)
+	}
+	if state.DependencyState == nil {
+		state.DependencyState = make(DependencyState)
+	}
 
-	// Here you would typically load this from a persistent store
-	// For this example, we'll just log it
-	w.LogManager.Logger.Debug().Msgf("Loaded worker state: %s", stateKey)
+	w.stateMu.Lock()
+	w.logState = state
+	w.stateMu.Unlock()
 
-	// If you had actual state data, you would unmarshal it like this:
-	// err := json.Unmarshal(stateData, &w.workerState)
-	// if err != nil {
-	//     log.Printf("Failed to unmarshal worker state: %v", err)
-	// }
-}
+	w.LogManager.Logger.Debug().
+		Uint64("LastOffset", state.LastOffset).
+		Msgf("Loaded worker state for task %s in orchestration %s", w.TaskID, orchestrationID)
 
-func mergeValueMapsToJson(src map[string]json.RawMessage) (json.RawMessage, error) {
-	out := make(map[string]any)
-	for _, input := range src {
-		if err := json.Unmarshal(input, &out); err != nil {
-			return nil, err
-		}
-	}
-	return json.Marshal(out)
+	return true
 }
 
 func containsAll(s map[string]json.RawMessage, e map[string]struct{}) bool {
@@ -306,6 +370,11 @@ func calculateBackoff(attempt int) time.Duration {
 }
 
 func isRetryableError(err error) bool {
+	var schemaErr *SchemaValidationError
+	if errors.As(err, &schemaErr) {
+		return false
+	}
+
 	// Implement logic to determine if the error is retryable
 	// For example, timeouts and connection errors might be retryable,
 	// while validation errors might not be.
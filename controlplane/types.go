@@ -13,16 +13,21 @@ import (
 )
 
 type ControlPlane struct {
-	projects             map[string]*Project
-	services             map[string]map[string]*ServiceInfo
+	store                Store
+	projectsMu           sync.RWMutex
+	apiKeys              map[string]*APIKey
+	apiKeysMu            sync.RWMutex
+	admin                *AdminCredential
+	adminMu              sync.RWMutex
 	servicesMu           sync.RWMutex
-	orchestrationStore   map[string]*Orchestration
 	orchestrationStoreMu sync.RWMutex
 	LogManager           *LogManager
 	logWorkers           map[string]map[string]context.CancelFunc
 	workerMu             sync.RWMutex
 	WebSocketManager     *WebSocketManager
-	openAIKey            string
+	deliverer            *webhookDeliverer
+	llm                  LLMProvider
+	planCache            *planCache
 	Logger               zerolog.Logger
 }
 
@@ -38,12 +43,19 @@ type WebSocketQueuedMessage struct {
 
 type WebSocketCallback func(json.RawMessage, error)
 
+// pendingTask is a dispatched task awaiting its result, which must conform to the service's output schema.
+type pendingTask struct {
+	serviceID    string
+	outputSchema Spec
+	callback     WebSocketCallback
+}
+
 type WebSocketManager struct {
 	melody            *melody.Melody
 	logger            zerolog.Logger
 	connMap           map[string]*melody.Session
 	connMu            sync.RWMutex
-	taskCallbacks     map[string]WebSocketCallback
+	taskCallbacks     map[string]pendingTask
 	callbacksMu       sync.RWMutex
 	messageQueues     map[string]*WebSocketMessageQueue
 	messageQueuesMu   sync.RWMutex
@@ -53,9 +65,14 @@ type WebSocketManager struct {
 }
 
 type Project struct {
-	ID      string `json:"id"`
-	APIKey  string `json:"apiKey"`
-	Webhook string `json:"webhook"`
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	APIKey string `json:"apiKey,omitempty"`
+	// Webhook is only accepted when registering a project, it is added to the project's Webhooks
+	Webhook  string     `json:"webhook,omitempty"`
+	Webhooks []*Webhook `json:"webhooks,omitempty"`
+	// WebhookSecret signs the payloads posted to the project's webhooks
+	WebhookSecret string `json:"-"`
 }
 
 type OrchestrationState struct {
@@ -83,6 +100,7 @@ type LogManager struct {
 	logs           map[string]*Log
 	orchestrations map[string]*OrchestrationState
 	mu             sync.RWMutex
+	dir            string
 	retention      time.Duration
 	cleanupTicker  *time.Ticker
 	webhookClient  *http.Client
@@ -95,6 +113,7 @@ type Log struct {
 	CurrentOffset uint64
 	mu            sync.RWMutex
 	lastAccessed  time.Time // For cleanup
+	segments      *SegmentLog
 }
 
 type DependencyState map[string]json.RawMessage
@@ -124,12 +143,15 @@ type FailureTracker struct {
 }
 
 type TaskWorker struct {
-	ServiceID    string
-	TaskID       string
-	Dependencies DependencyKeys
-	LogManager   *LogManager
-	logState     *LogState
-	stateMu      sync.Mutex
+	ServiceID string
+	// ServiceVersion is the version of the service the task was planned with
+	ServiceVersion int64
+	TaskID         string
+	Dependencies   DependencyKeys
+	Input          map[string]Source
+	LogManager     *LogManager
+	logState       *LogState
+	stateMu        sync.Mutex
 }
 
 type Task struct {
@@ -145,13 +167,22 @@ type Task struct {
 // Source is either user input or the subtask Id of where the value is expected from
 type Source string
 
+// Spec describes a payload with a practical subset of JSON Schema. Object properties can be registered
+// either as a "properties" map or as a "fields" list of named specs, see UnmarshalJSON.
 type Spec struct {
-	Type       string     `json:"type"`
-	Properties Properties `json:"properties,omitempty"`
-	Required   []string   `json:"required,omitempty"`
-	Format     string     `json:"format,omitempty"`
-	Minimum    int        `json:"minimum,omitempty"`
-	Maximum    int        `json:"maximum,omitempty"`
+	Type        string     `json:"type"`
+	Description string     `json:"description,omitempty"`
+	Properties  Properties `json:"properties,omitempty"`
+	Required    []string   `json:"required,omitempty"`
+	Items       *Spec      `json:"items,omitempty"`
+	Enum        []any      `json:"enum,omitempty"`
+	Nullable    bool       `json:"nullable,omitempty"`
+	Format      string     `json:"format,omitempty"`
+	Pattern     string     `json:"pattern,omitempty"`
+	MinLength   *int       `json:"minLength,omitempty"`
+	MaxLength   *int       `json:"maxLength,omitempty"`
+	Minimum     *float64   `json:"minimum,omitempty"`
+	Maximum     *float64   `json:"maximum,omitempty"`
 }
 
 type Properties map[string]Spec
@@ -172,18 +203,32 @@ type ServiceInfo struct {
 }
 
 type Orchestration struct {
-	ID        string              `json:"id"`
-	ProjectID string              `json:"-"`
-	Action    Action              `json:"action"`
-	Params    ActionParams        `json:"data"`
+	ID        string       `json:"id"`
+	ProjectID string       `json:"-"`
+	Action    Action       `json:"action"`
+	Params    ActionParams `json:"data"`
+	// Workflow names the project workflow to run instead of planning the action, an orchestration
+	// submitted with a Plan runs that plan instead
+	Workflow  string              `json:"workflow,omitempty"`
 	Plan      *ServiceCallingPlan `json:"plan"`
 	Results   []json.RawMessage   `json:"results"`
 	Status    Status              `json:"status"`
 	Error     json.RawMessage     `json:"error,omitempty"`
 	Timestamp time.Time           `json:"timestamp"`
+	// PlanningAttempts records the plans the LLM proposed and why they were rejected, if they were
+	PlanningAttempts []PlanningAttempt `json:"planning_attempts,omitempty"`
+	// PlanCache is hit when the plan was reused from an earlier orchestration of the same action
+	PlanCache string `json:"plan_cache,omitempty"`
 	taskZero  json.RawMessage
 }
 
+type PlanningAttempt struct {
+	Attempt   int                 `json:"attempt"`
+	Plan      *ServiceCallingPlan `json:"plan,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	Timestamp time.Time           `json:"timestamp"`
+}
+
 type Action struct {
 	Type    string `json:"type"`
 	Content string `json:"content"`
@@ -201,6 +246,7 @@ type ServiceCallingPlan struct {
 	ProjectID      string          `json:"-"`
 	Tasks          []*SubTask      `json:"tasks"`
 	ParallelGroups []ParallelGroup `json:"parallel_groups"`
+	DAG            *ExecutionDAG   `json:"dag,omitempty"`
 }
 
 type ParallelGroup []string
@@ -211,6 +257,7 @@ type DependencyKeys map[string]struct{}
 type SubTask struct {
 	ID             string            `json:"id"`
 	Service        string            `json:"service"`
+	ServiceVersion int64             `json:"service_version,omitempty"`
 	ServiceDetails string            `json:"service_details"`
 	Input          map[string]Source `json:"input"`
 	Status         Status            `json:"status,omitempty"`
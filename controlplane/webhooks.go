@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventOrchestrationCompleted     EventType = "orchestration.completed"
+	EventOrchestrationFailed        EventType = "orchestration.failed"
+	EventOrchestrationNotActionable EventType = "orchestration.not_actionable"
+	EventTaskCompleted              EventType = "task.completed"
+	EventTaskFailed                 EventType = "task.failed"
+)
+
+var AllEventTypes = []EventType{
+	EventOrchestrationCompleted,
+	EventOrchestrationFailed,
+	EventOrchestrationNotActionable,
+	EventTaskCompleted,
+	EventTaskFailed,
+}
+
+// DefaultWebhookEvents are the events a webhook subscribes to when none are given, i.e. every orchestration outcome.
+var DefaultWebhookEvents = []EventType{
+	EventOrchestrationCompleted,
+	EventOrchestrationFailed,
+	EventOrchestrationNotActionable,
+}
+
+type Webhook struct {
+	ID        string      `json:"id"`
+	URL       string      `json:"url"`
+	Events    []EventType `json:"events"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+// WebhookEvent is the payload posted to webhooks, orchestration events keep the fields of the original result payload.
+type WebhookEvent struct {
+	ID              string            `json:"id"`
+	Type            EventType         `json:"type"`
+	Timestamp       time.Time         `json:"timestamp"`
+	OrchestrationID string            `json:"orchestrationId"`
+	Results         []json.RawMessage `json:"results,omitempty"`
+	Status          Status            `json:"status,omitempty"`
+	Error           json.RawMessage   `json:"error,omitempty"`
+	TaskID          string            `json:"taskId,omitempty"`
+	ServiceID       string            `json:"serviceId,omitempty"`
+	Output          json.RawMessage   `json:"output,omitempty"`
+}
+
+// MarshalJSON always includes the results of orchestration events, as the original result payload did,
+// even when the orchestration failed without any.
+func (e WebhookEvent) MarshalJSON() ([]byte, error) {
+	type event WebhookEvent
+	switch e.Type {
+	case EventOrchestrationCompleted, EventOrchestrationFailed, EventOrchestrationNotActionable:
+		return json.Marshal(struct {
+			*event
+			Results []json.RawMessage `json:"results"`
+		}{(*event)(&e), e.Results})
+	}
+	return json.Marshal((*event)(&e))
+}
+
+func ParseEventType(val string) (EventType, error) {
+	eventType := EventType(strings.ToLower(strings.TrimSpace(val)))
+	if !slices.Contains(AllEventTypes, eventType) {
+		return "", fmt.Errorf("invalid event type: %s", val)
+	}
+	return eventType, nil
+}
+
+func (w *Webhook) Subscribes(eventType EventType) bool {
+	return slices.Contains(w.Events, eventType)
+}
+
+func newWebhook(url string, events []EventType) *Webhook {
+	if len(events) == 0 {
+		events = DefaultWebhookEvents
+	}
+	events = slices.Clone(events)
+	slices.Sort(events)
+
+	return &Webhook{
+		ID:        uuid.New().String(),
+		URL:       url,
+		Events:    slices.Compact(events),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func (p *ControlPlane) ListWebhooks(projectID string) ([]*Webhook, error) {
+	project, err := p.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return project.Webhooks, nil
+}
+
+// AddWebhook registers a webhook for the project, subscribing it to the default events if none are given.
+func (p *ControlPlane) AddWebhook(projectID, url string, events []EventType) (*Webhook, error) {
+	webhook := newWebhook(url, events)
+
+	err := p.updateProjectWebhooks(projectID, func(webhooks []*Webhook) ([]*Webhook, error) {
+		return append(webhooks, webhook), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.Logger.Debug().
+		Str("ProjectID", projectID).
+		Str("WebhookID", webhook.ID).
+		Msg("Added webhook")
+	return webhook, nil
+}
+
+// UpdateWebhook changes a webhook's URL and, when given, the events it subscribes to.
+func (p *ControlPlane) UpdateWebhook(projectID, webhookID, url string, events []EventType) (*Webhook, error) {
+	var updated *Webhook
+	err := p.updateProjectWebhooks(projectID, func(webhooks []*Webhook) ([]*Webhook, error) {
+		idx := slices.IndexFunc(webhooks, func(webhook *Webhook) bool { return webhook.ID == webhookID })
+		if idx < 0 {
+			return nil, fmt.Errorf("webhook %s for project %s: %w", webhookID, projectID, ErrNotFound)
+		}
+
+		updated = newWebhook(url, events)
+		updated.ID = webhookID
+		updated.CreatedAt = webhooks[idx].CreatedAt
+		if url == "" {
+			updated.URL = webhooks[idx].URL
+		}
+		if len(events) == 0 {
+			updated.Events = webhooks[idx].Events
+		}
+		webhooks[idx] = updated
+		return webhooks, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (p *ControlPlane) RemoveWebhook(projectID, webhookID string) error {
+	return p.updateProjectWebhooks(projectID, func(webhooks []*Webhook) ([]*Webhook, error) {
+		idx := slices.IndexFunc(webhooks, func(webhook *Webhook) bool { return webhook.ID == webhookID })
+		if idx < 0 {
+			return nil, fmt.Errorf("webhook %s for project %s: %w", webhookID, projectID, ErrNotFound)
+		}
+		return slices.Delete(webhooks, idx, idx+1), nil
+	})
+}
+
+func (p *ControlPlane) updateProjectWebhooks(projectID string, update func([]*Webhook) ([]*Webhook, error)) error {
+	p.projectsMu.Lock()
+	defer p.projectsMu.Unlock()
+
+	project, err := p.store.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := update(slices.Clone(project.Webhooks))
+	if err != nil {
+		return err
+	}
+	project.Webhooks = webhooks
+
+	if err := p.store.SaveProject(project); err != nil {
+		return fmt.Errorf("failed to save project %s: %w", project.ID, err)
+	}
+	return nil
+}
+
+// migrateProjectWebhook turns the single webhook of projects created before multiple webhooks existed
+// into a webhook subscribed to the default events, callers should hold the projectsMu lock.
+func (p *ControlPlane) migrateProjectWebhook(project *Project) error {
+	project.Webhooks = append(project.Webhooks, newWebhook(project.Webhook, DefaultWebhookEvents))
+	project.Webhook = ""
+
+	if err := p.store.SaveProject(project); err != nil {
+		return fmt.Errorf("failed to migrate webhook for project %s: %w", project.ID, err)
+	}
+
+	p.Logger.Info().Str("ProjectID", project.ID).Msg("Migrated project webhook")
+	return nil
+}
+
+// migrateProjectWebhookSecret callers should hold the projectsMu lock.
+func (p *ControlPlane) migrateProjectWebhookSecret(project *Project) error {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return err
+	}
+	project.WebhookSecret = secret
+
+	if err := p.store.SaveProject(project); err != nil {
+		return fmt.Errorf("failed to save webhook secret for project %s: %w", project.ID, err)
+	}
+	return nil
+}
+
+// DispatchEvent queues a delivery of the event for each of the project's webhooks subscribed to it.
+// The project is read straight from the store, as events are dispatched while holding the LogManager's
+// and orchestration locks that DeleteProject takes after the projects lock.
+func (p *ControlPlane) DispatchEvent(projectID string, event *WebhookEvent) error {
+	project, err := p.store.GetProject(projectID)
+	if err != nil {
+		return fmt.Errorf("project %s not found", projectID)
+	}
+
+	var subscribed []*Webhook
+	for _, webhook := range project.Webhooks {
+		if webhook.Subscribes(event.Type) {
+			subscribed = append(subscribed, webhook)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	p.Logger.Debug().
+		Str("OrchestrationID", event.OrchestrationID).
+		Str("ProjectID", project.ID).
+		Str("Event", string(event.Type)).
+		Int("Webhooks", len(subscribed)).
+		Msg("Queueing webhook deliveries")
+
+	_, err = p.queueDeliveries(project.ID, event, subscribed)
+	return err
+}
+
+// NotifyEvent dispatches the event, failures are only logged.
+func (p *ControlPlane) NotifyEvent(projectID string, event *WebhookEvent) {
+	if err := p.DispatchEvent(projectID, event); err != nil {
+		p.Logger.Error().
+			Err(err).
+			Str("OrchestrationID", event.OrchestrationID).
+			Str("Event", string(event.Type)).
+			Msg("Failed to dispatch event to webhooks")
+	}
+}
+
+func newOrchestrationEvent(orchestration *Orchestration) *WebhookEvent {
+	eventType := EventOrchestrationFailed
+	switch orchestration.Status {
+	case Completed:
+		eventType = EventOrchestrationCompleted
+	case NotActionable:
+		eventType = EventOrchestrationNotActionable
+	}
+
+	return &WebhookEvent{
+		ID:              uuid.New().String(),
+		Type:            eventType,
+		Timestamp:       time.Now().UTC(),
+		OrchestrationID: orchestration.ID,
+		Results:         orchestration.Results,
+		Status:          orchestration.Status,
+		Error:           orchestration.Error,
+	}
+}
+
+func newTaskEvent(eventType EventType, orchestrationID, taskID, serviceID string, value json.RawMessage) *WebhookEvent {
+	event := &WebhookEvent{
+		ID:              uuid.New().String(),
+		Type:            eventType,
+		Timestamp:       time.Now().UTC(),
+		OrchestrationID: orchestrationID,
+		TaskID:          taskID,
+		ServiceID:       serviceID,
+	}
+	if eventType == EventTaskFailed {
+		event.Error = value
+	} else {
+		event.Output = value
+	}
+	return event
+}
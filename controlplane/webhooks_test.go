@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestDispatchEventOnlyReachesSubscribedWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]EventType{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], event.Type)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	plane, store := newTestPlane(t, nil)
+	_ = store.SaveProject(&Project{ID: "p1", Webhook: server.URL + "/legacy"})
+	if err := plane.MigrateProjects(); err != nil {
+		t.Fatalf("failed to migrate projects: %v", err)
+	}
+	if _, err := plane.AddWebhook("p1", server.URL+"/tasks", []EventType{EventTaskFailed, EventTaskCompleted}); err != nil {
+		t.Fatalf("failed to add webhook: %v", err)
+	}
+
+	if err := plane.DispatchEvent("p1", newTaskEvent(EventTaskCompleted, "o1", "task1", "s1", json.RawMessage(`{}`))); err != nil {
+		t.Fatalf("failed to dispatch task event: %v", err)
+	}
+	orchestration := &Orchestration{ID: "o1", Status: Completed}
+	if err := plane.DispatchEvent("p1", newOrchestrationEvent(orchestration)); err != nil {
+		t.Fatalf("failed to dispatch orchestration event: %v", err)
+	}
+	deliverDueWebhooks(plane)
+
+	if got := received["/tasks"]; len(got) != 1 || got[0] != EventTaskCompleted {
+		t.Errorf("expected the task webhook to only receive the task event, got %v", got)
+	}
+	// The migrated webhook keeps receiving orchestration results only
+	if got := received["/legacy"]; len(got) != 1 || got[0] != EventOrchestrationCompleted {
+		t.Errorf("expected the migrated webhook to only receive the orchestration event, got %v", got)
+	}
+}
+
+func TestOrchestrationEventsAlwaysIncludeResults(t *testing.T) {
+	failed, _ := json.Marshal(newOrchestrationEvent(&Orchestration{ID: "o1", Status: Failed, Error: json.RawMessage(`"boom"`)}))
+	var payload map[string]any
+	_ = json.Unmarshal(failed, &payload)
+	if results, ok := payload["results"]; !ok || results != nil {
+		t.Errorf("expected a failed orchestration's payload to include null results, got %s", failed)
+	}
+
+	task, _ := json.Marshal(newTaskEvent(EventTaskCompleted, "o1", "task1", "s1", json.RawMessage(`{}`)))
+	payload = nil
+	_ = json.Unmarshal(task, &payload)
+	if _, ok := payload["results"]; ok {
+		t.Errorf("expected task events not to include results, got %s", task)
+	}
+}
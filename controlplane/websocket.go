@@ -20,7 +20,7 @@ func NewWebSocketManager(logger zerolog.Logger) *WebSocketManager {
 		melody:            m,
 		logger:            logger,
 		connMap:           make(map[string]*melody.Session),
-		taskCallbacks:     make(map[string]WebSocketCallback),
+		taskCallbacks:     make(map[string]pendingTask),
 		messageQueues:     make(map[string]*WebSocketMessageQueue),
 		messageExpiration: time.Hour * 24, // Keep messages for 24 hours
 		pingInterval:      m.Config.PingPeriod,
@@ -157,7 +157,7 @@ func (wsm *WebSocketManager) handleTaskResult(message struct {
 	Error       string          `json:"error,omitempty"`
 }) {
 	wsm.callbacksMu.RLock()
-	callback, exists := wsm.taskCallbacks[message.ExecutionID]
+	task, exists := wsm.taskCallbacks[message.ExecutionID]
 	wsm.callbacksMu.RUnlock()
 
 	if !exists {
@@ -167,9 +167,11 @@ func (wsm *WebSocketManager) handleTaskResult(message struct {
 
 	wsm.callbacksMu.Lock()
 	if message.Error != "" {
-		callback(nil, fmt.Errorf(message.Error))
+		task.callback(nil, fmt.Errorf(message.Error))
+	} else if err := validatePayload(task.serviceID, "output", task.outputSchema, message.Result); err != nil {
+		task.callback(nil, err)
 	} else {
-		callback(message.Result, nil)
+		task.callback(message.Result, nil)
 	}
 	wsm.callbacksMu.Unlock()
 
@@ -227,10 +229,32 @@ func (wsm *WebSocketManager) QueueMessage(serviceID string, message []byte) {
 	queue.PushBack(&WebSocketQueuedMessage{Message: message, Time: time.Now()})
 }
 
-func (wsm *WebSocketManager) RegisterTaskCallback(executionID string, callback WebSocketCallback) {
+// RemoveService closes the service's connection, if any, and drops the messages queued for it.
+func (wsm *WebSocketManager) RemoveService(serviceID string) {
+	wsm.connMu.Lock()
+	session, connected := wsm.connMap[serviceID]
+	delete(wsm.connMap, serviceID)
+	wsm.connMu.Unlock()
+
+	if connected {
+		if err := session.Close(); err != nil {
+			wsm.logger.Warn().Str("ServiceID", serviceID).Err(err).Msg("Failed to close connection")
+		}
+	}
+
+	wsm.messageQueuesMu.Lock()
+	delete(wsm.messageQueues, serviceID)
+	wsm.messageQueuesMu.Unlock()
+
+	wsm.logger.Info().Str("ServiceID", serviceID).Msg("Removed WebSocket connection and queued messages")
+}
+
+// RegisterTaskCallback registers the callback receiving a dispatched task's result, results that do not
+// conform to the output schema are reported to the callback as errors.
+func (wsm *WebSocketManager) RegisterTaskCallback(executionID, serviceID string, outputSchema Spec, callback WebSocketCallback) {
 	wsm.callbacksMu.Lock()
 	defer wsm.callbacksMu.Unlock()
-	wsm.taskCallbacks[executionID] = callback
+	wsm.taskCallbacks[executionID] = pendingTask{serviceID: serviceID, outputSchema: outputSchema, callback: callback}
 }
 
 func (wsm *WebSocketManager) UnregisterTaskCallback(executionID string) {
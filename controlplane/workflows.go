@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidWorkflow  = errors.New("invalid workflow")
+	workflowNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+)
+
+// Workflow is a named plan registered for a project. Orchestrations naming a workflow run its plan as is,
+// without planning their action with the LLM.
+type Workflow struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Params are the action params the workflow's tasks reference through task0, they are required
+	// by the orchestrations running the workflow
+	Params    []string            `json:"params"`
+	Plan      *ServiceCallingPlan `json:"plan"`
+	ProjectID string              `json:"-"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
+
+// ListWorkflows returns the project's workflows ordered by name.
+func (p *ControlPlane) ListWorkflows(projectID string) ([]*Workflow, error) {
+	workflows, err := p.store.ListWorkflows(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflows for project %s: %w", projectID, err)
+	}
+
+	slices.SortFunc(workflows, func(a, b *Workflow) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	if workflows == nil {
+		workflows = []*Workflow{}
+	}
+	return workflows, nil
+}
+
+func (p *ControlPlane) GetWorkflow(projectID, name string) (*Workflow, error) {
+	return p.store.GetWorkflow(projectID, name)
+}
+
+// SaveWorkflow registers or replaces the project's named workflow once its plan validates against the
+// project's services.
+func (p *ControlPlane) SaveWorkflow(projectID, name, description string, plan *ServiceCallingPlan) (*Workflow, error) {
+	if !workflowNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: names start with a letter or digit followed by up to 63 letters, digits, '_', '.' or '-'", ErrInvalidWorkflow)
+	}
+	if plan == nil || len(plan.Tasks) == 0 {
+		return nil, fmt.Errorf("%w: a plan with tasks is required", ErrInvalidWorkflow)
+	}
+
+	services, err := p.discoverProjectServices(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.ProjectID = projectID
+	params := workflowParams(plan)
+
+	// Params are only known when the workflow runs, so any field referenced through task0 is accepted
+	check := plan.clone()
+	taskZero := taskZeroWithParams(check, nil)
+	for _, param := range params {
+		taskZero.Input[param] = ""
+	}
+	if _, _, err := p.checkCallingPlan(services, check); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidWorkflow, err.Error())
+	}
+
+	now := time.Now().UTC()
+	workflow := &Workflow{
+		Name:        name,
+		Description: description,
+		Params:      params,
+		Plan:        plan,
+		ProjectID:   projectID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if existing, err := p.store.GetWorkflow(projectID, name); err == nil {
+		workflow.CreatedAt = existing.CreatedAt
+	}
+
+	if err := p.store.SaveWorkflow(workflow); err != nil {
+		return nil, fmt.Errorf("failed to save workflow %s: %w", name, err)
+	}
+
+	p.Logger.Debug().
+		Str("ProjectID", projectID).
+		Str("Workflow", name).
+		Msg("Saved workflow")
+	return workflow, nil
+}
+
+func (p *ControlPlane) DeleteWorkflow(projectID, name string) error {
+	return p.store.DeleteWorkflow(projectID, name)
+}
+
+// planStaticOrchestration prepares an orchestration submitted with its own plan or naming one of the
+// project's workflows. The plan is validated like the LLM's plans are, but never repaired.
+func (p *ControlPlane) planStaticOrchestration(orchestration *Orchestration, services []*ServiceInfo) {
+	callingPlan := orchestration.Plan
+	if orchestration.Workflow != "" {
+		if callingPlan != nil {
+			orchestration.Plan = nil
+			p.failOrchestration(orchestration, "An orchestration takes either a plan or a workflow, not both")
+			return
+		}
+
+		workflow, err := p.store.GetWorkflow(orchestration.ProjectID, orchestration.Workflow)
+		if err != nil {
+			p.failOrchestration(orchestration, fmt.Sprintf("Error loading workflow %s: %s", orchestration.Workflow, err.Error()))
+			return
+		}
+		callingPlan = workflow.Plan
+	}
+
+	callingPlan.ProjectID = orchestration.ProjectID
+	taskZeroWithParams(callingPlan, orchestration.Params)
+
+	taskZeroInput, onlyServicesCallingPlan, err := p.checkCallingPlan(services, callingPlan)
+	if err != nil {
+		orchestration.Plan = callingPlan
+		p.failOrchestration(orchestration, err.Error())
+		return
+	}
+
+	orchestration.Plan = onlyServicesCallingPlan
+	orchestration.taskZero = taskZeroInput
+}
+
+func (p *ControlPlane) failOrchestration(orchestration *Orchestration, reason string) {
+	orchestration.Status = Failed
+	marshaledErr, _ := json.Marshal(reason)
+	orchestration.Error = marshaledErr
+}
+
+// taskZeroWithParams adds the action's params to the plan's task0, adding task0 when the plan has none.
+// Params override task0's constants.
+func taskZeroWithParams(plan *ServiceCallingPlan, params ActionParams) *SubTask {
+	var taskZero *SubTask
+	for _, subTask := range plan.Tasks {
+		if strings.EqualFold(subTask.ID, TaskZero) {
+			taskZero = subTask
+			break
+		}
+	}
+	if taskZero == nil {
+		taskZero = &SubTask{ID: TaskZero}
+		plan.Tasks = append([]*SubTask{taskZero}, plan.Tasks...)
+	}
+	if taskZero.Input == nil {
+		taskZero.Input = make(map[string]Source)
+	}
+
+	for _, param := range params {
+		taskZero.Input[param.Field] = Source(param.Value)
+	}
+	return taskZero
+}
+
+// workflowParams returns the task0 fields referenced by the plan's tasks that task0 has no constant for.
+func workflowParams(plan *ServiceCallingPlan) []string {
+	constants := make(map[string]Source)
+	for _, subTask := range plan.Tasks {
+		if strings.EqualFold(subTask.ID, TaskZero) {
+			constants = subTask.Input
+		}
+	}
+
+	params := make(map[string]struct{})
+	for _, subTask := range plan.Tasks {
+		for _, source := range subTask.Input {
+			taskID, field, ok := parseTaskReference(string(source))
+			if !ok || !strings.EqualFold(taskID, TaskZero) {
+				continue
+			}
+			if _, constant := constants[field]; !constant {
+				params[field] = struct{}{}
+			}
+		}
+	}
+	return sortedKeys(params)
+}
+
+func (w *Workflow) clone() *Workflow {
+	out := *w
+	out.Params = slices.Clone(w.Params)
+	if w.Plan != nil {
+		out.Plan = w.Plan.clone()
+	}
+	return &out
+}
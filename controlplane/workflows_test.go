@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowsRunWithoutPlanning(t *testing.T) {
+	service := echoService()
+	service.Schema.Input.Properties["prefix"] = Spec{Type: "string"}
+	llm := NewFakeLLM()
+	plane, _ := newTestPlane(t, llm, service)
+
+	newPlan := func(inputKey string) *ServiceCallingPlan {
+		return &ServiceCallingPlan{Tasks: []*SubTask{
+			{ID: "task0", Input: map[string]Source{"prefix": "re: "}},
+			{ID: "task1", Service: "s1", Input: map[string]Source{inputKey: "$task0.message", "prefix": "$task0.prefix"}},
+			{ID: "task2", Service: "s1", Input: map[string]Source{"message": "$task1.echo"}},
+		}}
+	}
+
+	if _, err := plane.SaveWorkflow("p1", "bad name", "", newPlan("message")); !errors.Is(err, ErrInvalidWorkflow) {
+		t.Errorf("expected an invalid name to be rejected, got %v", err)
+	}
+	if _, err := plane.SaveWorkflow("p1", "echo", "", newPlan("msg")); !errors.Is(err, ErrInvalidWorkflow) || !strings.Contains(err.Error(), "input msg not supported") {
+		t.Errorf("expected an invalid plan to be rejected, got %v", err)
+	}
+
+	workflow, err := plane.SaveWorkflow("p1", "echo", "Echoes twice", newPlan("message"))
+	if err != nil {
+		t.Fatalf("failed to save workflow: %v", err)
+	}
+	if len(workflow.Params) != 1 || workflow.Params[0] != "message" {
+		t.Errorf("expected the workflow to require the message param, got %v", workflow.Params)
+	}
+	if workflows, _ := plane.ListWorkflows("p1"); len(workflows) != 1 || workflows[0].Description != "Echoes twice" {
+		t.Errorf("unexpected workflows: %+v", workflows)
+	}
+
+	orchestration := &Orchestration{
+		ID:        "o1",
+		ProjectID: "p1",
+		Workflow:  "echo",
+		Params:    ActionParams{{Field: "message", Value: "hi"}},
+		Status:    Pending,
+	}
+	plane.PlanOrchestration(orchestration)
+	if !orchestration.Executable() {
+		t.Fatalf("expected an executable orchestration, got %s: %s", orchestration.Status.String(), orchestration.Error)
+	}
+	if len(orchestration.Plan.Tasks) != 2 || orchestration.Plan.DAG == nil || string(orchestration.taskZero) != `{"message":"hi","prefix":"re: "}` {
+		t.Errorf("unexpected plan %+v with task zero %s", orchestration.Plan, orchestration.taskZero)
+	}
+	if prompts := llm.Prompts(); len(prompts) != 0 {
+		t.Errorf("expected workflows to skip planning, got %d prompts", len(prompts))
+	}
+
+	missingParam := &Orchestration{ID: "o2", ProjectID: "p1", Workflow: "echo", Status: Pending}
+	plane.PlanOrchestration(missingParam)
+	if missingParam.Status != Failed || !strings.Contains(string(missingParam.Error), "task0 has no message field") {
+		t.Errorf("expected a missing param to fail the orchestration, got %s: %s", missingParam.Status.String(), missingParam.Error)
+	}
+
+	inline := &Orchestration{
+		ID:        "o3",
+		ProjectID: "p1",
+		Params:    ActionParams{{Field: "message", Value: "hey"}},
+		Plan:      newPlan("message"),
+		Status:    Pending,
+	}
+	plane.PlanOrchestration(inline)
+	if !inline.Executable() || len(inline.Plan.Tasks) != 2 || len(llm.Prompts()) != 0 {
+		t.Errorf("expected the submitted plan to run without planning, got %s: %s", inline.Status.String(), inline.Error)
+	}
+
+	if err := plane.DeleteWorkflow("p1", "echo"); err != nil {
+		t.Fatalf("failed to delete workflow: %v", err)
+	}
+	if _, err := plane.GetWorkflow("p1", "echo"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the deleted workflow to be missing, got %v", err)
+	}
+}
+
+func TestWorkflowsPinServiceVersions(t *testing.T) {
+	service := echoService()
+	plane, _ := newTestPlane(t, NewFakeLLM(), service)
+
+	// Version 2 adds an optional prefix input
+	update := echoService()
+	update.ProjectID = "p1"
+	update.Schema.Input.Properties["prefix"] = Spec{Type: "string"}
+	if _, err := plane.RegisterOrUpdateService(update, true); err != nil {
+		t.Fatalf("failed to update service: %v", err)
+	}
+
+	versions, err := plane.ServiceVersions("p1", service.ID)
+	if err != nil || len(versions) != 2 || versions[0].Version != 1 || versions[0].Schema.InputIncludes("prefix") {
+		t.Fatalf("expected both versions to be kept, got %+v, err %v", versions, err)
+	}
+
+	newPlan := func(version int64, inputs ...string) *ServiceCallingPlan {
+		input := map[string]Source{}
+		for _, field := range inputs {
+			input[field] = Source("$task0." + field)
+		}
+		return &ServiceCallingPlan{Tasks: []*SubTask{
+			{ID: "task0", Input: map[string]Source{}},
+			{ID: "task1", Service: service.ID, ServiceVersion: version, Input: input},
+		}}
+	}
+
+	if _, err := plane.SaveWorkflow("p1", "latest", "", newPlan(0, "message", "prefix")); err != nil {
+		t.Errorf("expected a plan for the latest schema to be saved, got %v", err)
+	}
+	if _, err := plane.SaveWorkflow("p1", "old", "", newPlan(1, "message", "prefix")); !errors.Is(err, ErrInvalidWorkflow) || !strings.Contains(err.Error(), "input prefix not supported") {
+		t.Errorf("expected a plan pinned to the earlier schema to be validated against it, got %v", err)
+	}
+	if _, err := plane.SaveWorkflow("p1", "missing", "", newPlan(3, "message")); !errors.Is(err, ErrInvalidWorkflow) || !strings.Contains(err.Error(), "has no version 3") {
+		t.Errorf("expected a pin to a missing version to be rejected, got %v", err)
+	}
+	if _, err := plane.SaveWorkflow("p1", "pinned", "", newPlan(1, "message")); err != nil {
+		t.Fatalf("failed to save pinned workflow: %v", err)
+	}
+
+	orchestration := &Orchestration{
+		ID:        "o1",
+		ProjectID: "p1",
+		Workflow:  "pinned",
+		Params:    ActionParams{{Field: "message", Value: "hi"}},
+		Status:    Pending,
+	}
+	plane.PlanOrchestration(orchestration)
+	if !orchestration.Executable() {
+		t.Fatalf("expected an executable orchestration, got %s: %s", orchestration.Status.String(), orchestration.Error)
+	}
+	if task := orchestration.Plan.Tasks[0]; task.ServiceVersion != 1 {
+		t.Errorf("expected the task to record the pinned version, got %d", task.ServiceVersion)
+	}
+
+	inline := &Orchestration{
+		ID:        "o2",
+		ProjectID: "p1",
+		Params:    ActionParams{{Field: "message", Value: "hi"}},
+		Plan:      newPlan(0, "message"),
+		Status:    Pending,
+	}
+	plane.PlanOrchestration(inline)
+	if !inline.Executable() || inline.Plan.Tasks[0].ServiceVersion != 2 {
+		t.Errorf("expected an unpinned task to record the latest version, got %s: %s", inline.Status.String(), inline.Error)
+	}
+
+	// Tasks run on the connected service, so a pin cannot outlive a breaking change
+	breaking := echoServiceUpdate()
+	if _, err := plane.RegisterOrUpdateService(breaking, false); err != nil {
+		t.Fatalf("failed to update service: %v", err)
+	}
+	if _, err := plane.SaveWorkflow("p1", "stale", "", newPlan(1, "message")); !errors.Is(err, ErrInvalidWorkflow) || !strings.Contains(err.Error(), "incompatible with the running version 3") {
+		t.Errorf("expected a pin to an incompatible version to be rejected, got %v", err)
+	}
+}